@@ -0,0 +1,23 @@
+// Package auth validates the nickname/token pair a client presents in its
+// auth_request frame and resolves it to a stable user ID.
+package auth
+
+// TokenStore authenticates a client's nickname/token pair.
+type TokenStore interface {
+	// Authenticate returns the authenticated user's ID and true if
+	// nickname/token are valid together, or ("", false) otherwise.
+	Authenticate(nickname, token string) (userID string, ok bool)
+}
+
+// OpenTokenStore accepts any nickname/token pair, using the nickname itself
+// as the user ID. It exists so the server is usable with zero auth
+// configuration; InitAuth falls back to it and logs a warning.
+type OpenTokenStore struct{}
+
+// Authenticate succeeds for any non-empty nickname, regardless of token.
+func (OpenTokenStore) Authenticate(nickname, token string) (string, bool) {
+	if nickname == "" {
+		return "", false
+	}
+	return nickname, true
+}
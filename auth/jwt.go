@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// JWTTokenStore is the production backend: the token is an HMAC-SHA256
+// (HS256) signed JWT, and the user ID is its "sub" claim. If the token also
+// carries a "nickname" claim, it must match the nickname the client
+// presented.
+type JWTTokenStore struct {
+	Secret []byte
+}
+
+// Authenticate verifies token's signature and expiry and extracts its
+// subject as the user ID.
+func (s JWTTokenStore) Authenticate(nickname, token string) (string, bool) {
+	claims, err := verifyHS256(token, s.Secret)
+	if err != nil {
+		return "", false
+	}
+
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return "", false
+	}
+
+	if claimedNick, ok := claims["nickname"].(string); ok && claimedNick != "" && claimedNick != nickname {
+		return "", false
+	}
+
+	return sub, true
+}
+
+// verifyHS256 checks token's signature against secret and that it hasn't
+// expired, then returns its decoded claims.
+func verifyHS256(token string, secret []byte) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	header, payload, signature := parts[0], parts[1], parts[2]
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(header)
+	if err != nil {
+		return nil, errors.New("malformed header")
+	}
+	var alg struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerJSON, &alg); err != nil {
+		return nil, errors.New("malformed header")
+	}
+	if alg.Alg != "HS256" {
+		return nil, errors.New("unsupported signing algorithm")
+	}
+
+	wantSig := sign(header+"."+payload, secret)
+	gotSig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, errors.New("malformed signature")
+	}
+	if subtle.ConstantTimeCompare(gotSig, wantSig) != 1 {
+		return nil, errors.New("signature mismatch")
+	}
+
+	payloadJSON, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, errors.New("malformed payload")
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payloadJSON, &claims); err != nil {
+		return nil, errors.New("malformed claims")
+	}
+
+	if exp, ok := claims["exp"].(float64); ok && time.Now().Unix() > int64(exp) {
+		return nil, errors.New("token expired")
+	}
+
+	return claims, nil
+}
+
+func sign(signingInput string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
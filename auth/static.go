@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+)
+
+// StaticTokenStore is the dev backend: a fixed nickname -> token map,
+// typically loaded from a flag or environment variable. The user ID is the
+// nickname itself.
+type StaticTokenStore map[string]string
+
+// Authenticate checks token against the one configured for nickname, using
+// a constant-time comparison so token length/prefix can't be timed out.
+func (s StaticTokenStore) Authenticate(nickname, token string) (string, bool) {
+	want, ok := s[nickname]
+	if !ok || want == "" {
+		return "", false
+	}
+	if subtle.ConstantTimeCompare([]byte(want), []byte(token)) != 1 {
+		return "", false
+	}
+	return nickname, true
+}
+
+// ParseStaticTokens parses a "nickname:token,nickname:token" configuration
+// string, as accepted via the --auth-tokens flag, into a StaticTokenStore.
+func ParseStaticTokens(raw string) (StaticTokenStore, error) {
+	store := make(StaticTokenStore)
+	if raw == "" {
+		return store, nil
+	}
+
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		nickname, token, ok := strings.Cut(pair, ":")
+		if !ok || nickname == "" || token == "" {
+			return nil, fmt.Errorf("invalid auth token entry %q: want nickname:token", pair)
+		}
+		store[nickname] = token
+	}
+	return store, nil
+}
@@ -0,0 +1,37 @@
+// Package bridge relays chat messages between this server and external
+// chat networks (IRC, Matrix, webhooks), modeled on the Matterbridge
+// pattern: one adapter per network, fanned in and out by a Gateway.
+package bridge
+
+import (
+	"context"
+
+	"github.com/Akshaylinson/Realtime-chatapp-go/store"
+)
+
+// Bridge relays messages to and from a single external network endpoint,
+// e.g. one IRC channel or one Matrix room.
+type Bridge interface {
+	// Name identifies this bridge in logs and config links, and is used
+	// to prefix the Username of messages it relays in, to keep them from
+	// looping back out.
+	Name() string
+
+	// Start connects to the external network and begins relaying inbound
+	// messages onto the channel returned by Receive. It blocks until ctx
+	// is done or a fatal error occurs.
+	Start(ctx context.Context) error
+
+	// Send relays a locally-accepted message out to the external network.
+	Send(msg store.Message) error
+
+	// Receive returns the channel of messages received from the external
+	// network. It is closed once Start returns.
+	Receive() <-chan store.Message
+
+	// BotNick is the author name this bridge's own relayed messages show
+	// up as on the external network. The Gateway drops any inbound
+	// message whose author matches it, so a message this bridge just
+	// relayed out doesn't loop back in.
+	BotNick() string
+}
@@ -0,0 +1,62 @@
+package bridge
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GatewayConfig maps local rooms to the remote bridges they relay through,
+// e.g. room "general" linked to an IRC channel and a Matrix room:
+//
+//	rooms:
+//	  - room: general
+//	    links:
+//	      - name: libera
+//	        type: irc
+//	        irc:
+//	          server: irc.libera.chat:6697
+//	          tls: true
+//	          nick: myproject-bot
+//	          channel: "#myproject"
+//	      - name: matrix-home
+//	        type: matrix
+//	        matrix:
+//	          homeserver: https://matrix.org
+//	          user_id: "@myproject-bot:matrix.org"
+//	          access_token: ...
+//	          room_id: "!abc123:matrix.org"
+type GatewayConfig struct {
+	Rooms []RoomConfig `yaml:"rooms"`
+}
+
+// RoomConfig links one local room to every remote bridge in Links.
+type RoomConfig struct {
+	Room  string       `yaml:"room"`
+	Links []LinkConfig `yaml:"links"`
+}
+
+// LinkConfig names one bridge and carries the settings for its Type. Exactly
+// one of IRC, Matrix or Webhook should be set, matching Type.
+type LinkConfig struct {
+	Name    string         `yaml:"name"`
+	Type    string         `yaml:"type"` // "irc", "matrix", or "webhook"
+	IRC     *IRCConfig     `yaml:"irc,omitempty"`
+	Matrix  *MatrixConfig  `yaml:"matrix,omitempty"`
+	Webhook *WebhookConfig `yaml:"webhook,omitempty"`
+}
+
+// LoadConfig reads and parses a Gateway config file in YAML format.
+func LoadConfig(path string) (GatewayConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return GatewayConfig{}, fmt.Errorf("reading bridge config %s: %w", path, err)
+	}
+
+	var cfg GatewayConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return GatewayConfig{}, fmt.Errorf("parsing bridge config %s: %w", path, err)
+	}
+	return cfg, nil
+}
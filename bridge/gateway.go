@@ -0,0 +1,127 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/Akshaylinson/Realtime-chatapp-go/store"
+)
+
+// link binds one configured bridge to the local room it relays for.
+type link struct {
+	room   string
+	bridge Bridge
+}
+
+// Gateway is the central relay between local rooms and the external
+// bridges linked to them. It fans each locally-accepted message out to
+// every bridge linked to its room, and fans every message a bridge
+// receives back in to that bridge's local room.
+type Gateway struct {
+	links  []link
+	byRoom map[string][]Bridge
+}
+
+// NewGateway builds a Gateway from cfg, constructing one adapter per
+// configured link.
+func NewGateway(cfg GatewayConfig) (*Gateway, error) {
+	g := &Gateway{byRoom: make(map[string][]Bridge)}
+
+	for _, rc := range cfg.Rooms {
+		for _, lc := range rc.Links {
+			b, err := newBridge(lc)
+			if err != nil {
+				return nil, err
+			}
+			g.links = append(g.links, link{room: rc.Room, bridge: b})
+			g.byRoom[rc.Room] = append(g.byRoom[rc.Room], b)
+		}
+	}
+
+	return g, nil
+}
+
+// newBridge constructs the adapter named by lc.Type from its matching
+// config block.
+func newBridge(lc LinkConfig) (Bridge, error) {
+	switch lc.Type {
+	case "irc":
+		if lc.IRC == nil {
+			return nil, fmt.Errorf("bridge link %q: type irc requires an irc config block", lc.Name)
+		}
+		cfg := *lc.IRC
+		cfg.BridgeName = lc.Name
+		return NewIRCBridge(cfg), nil
+
+	case "matrix":
+		if lc.Matrix == nil {
+			return nil, fmt.Errorf("bridge link %q: type matrix requires a matrix config block", lc.Name)
+		}
+		cfg := *lc.Matrix
+		cfg.BridgeName = lc.Name
+		return NewMatrixBridge(cfg), nil
+
+	case "webhook":
+		if lc.Webhook == nil {
+			return nil, fmt.Errorf("bridge link %q: type webhook requires a webhook config block", lc.Name)
+		}
+		cfg := *lc.Webhook
+		cfg.BridgeName = lc.Name
+		return NewWebhookBridge(cfg), nil
+
+	default:
+		return nil, fmt.Errorf("bridge link %q: unknown type %q", lc.Name, lc.Type)
+	}
+}
+
+// Run starts every configured bridge and relays the messages each one
+// receives back into the chat, until ctx is done. save persists a relayed
+// message the same way a locally-sent one would be; deliver fans the saved
+// message out to this instance's local subscribers.
+func (g *Gateway) Run(ctx context.Context, save func(store.Message) (store.Message, error), deliver func(store.Message)) {
+	for _, l := range g.links {
+		l := l
+
+		go func() {
+			if err := l.bridge.Start(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("bridge %s: %v", l.bridge.Name(), err)
+			}
+		}()
+
+		go g.relayInbound(l, save, deliver)
+	}
+}
+
+// relayInbound reads every message l.bridge receives, rewrites it to avoid
+// loops and mark its origin, and hands it to save/deliver.
+func (g *Gateway) relayInbound(l link, save func(store.Message) (store.Message, error), deliver func(store.Message)) {
+	botNick := l.bridge.BotNick()
+
+	for msg := range l.bridge.Receive() {
+		if botNick != "" && msg.Username == botNick {
+			// Our own relayed message, echoed back by the network: drop
+			// it rather than looping it back into the room.
+			continue
+		}
+
+		msg.Room = l.room
+		msg.Username = l.bridge.Name() + "/" + msg.Username
+
+		saved, err := save(msg)
+		if err != nil {
+			log.Printf("bridge %s: saving message from %s: %v", l.bridge.Name(), msg.Username, err)
+			continue
+		}
+		deliver(saved)
+	}
+}
+
+// Relay sends msg out to every bridge linked to msg.Room.
+func (g *Gateway) Relay(msg store.Message) {
+	for _, b := range g.byRoom[msg.Room] {
+		if err := b.Send(msg); err != nil {
+			log.Printf("bridge %s: relaying message: %v", b.Name(), err)
+		}
+	}
+}
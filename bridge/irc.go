@@ -0,0 +1,116 @@
+package bridge
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	irc "gopkg.in/irc.v4"
+
+	"github.com/Akshaylinson/Realtime-chatapp-go/store"
+)
+
+// IRCConfig configures a single IRC network connection.
+type IRCConfig struct {
+	// BridgeName identifies this bridge in config links and log lines. It
+	// is filled in from the enclosing LinkConfig's Name, not parsed from
+	// YAML directly.
+	BridgeName string `yaml:"-"`
+	Server     string `yaml:"server"` // host:port
+	TLS        bool   `yaml:"tls"`
+	Nick       string `yaml:"nick"`
+	Channel    string `yaml:"channel"` // including the leading '#'
+}
+
+// IRCBridge relays messages to and from a single channel on one IRC
+// network, speaking the protocol via gopkg.in/irc.v4.
+type IRCBridge struct {
+	cfg IRCConfig
+
+	mu     sync.Mutex
+	client *irc.Client
+
+	recv chan store.Message
+}
+
+// NewIRCBridge creates an IRCBridge that will dial cfg.Server and join
+// cfg.Channel once started.
+func NewIRCBridge(cfg IRCConfig) *IRCBridge {
+	return &IRCBridge{cfg: cfg, recv: make(chan store.Message, 64)}
+}
+
+// Name returns the bridge's configured name.
+func (b *IRCBridge) Name() string { return b.cfg.BridgeName }
+
+// BotNick returns the nick this bridge connects to IRC as.
+func (b *IRCBridge) BotNick() string { return b.cfg.Nick }
+
+// Start dials the IRC server, registers, joins the configured channel, and
+// runs the client's read loop until ctx is done.
+func (b *IRCBridge) Start(ctx context.Context) error {
+	defer close(b.recv)
+
+	var conn net.Conn
+	var err error
+	if b.cfg.TLS {
+		conn, err = tls.Dial("tcp", b.cfg.Server, nil)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", b.cfg.Server)
+	}
+	if err != nil {
+		return fmt.Errorf("dialing %s: %w", b.cfg.Server, err)
+	}
+	defer conn.Close()
+
+	client := irc.NewClient(conn, irc.ClientConfig{
+		Nick:    b.cfg.Nick,
+		User:    b.cfg.Nick,
+		Name:    b.cfg.Nick,
+		Handler: irc.HandlerFunc(b.handle),
+	})
+
+	b.mu.Lock()
+	b.client = client
+	b.mu.Unlock()
+
+	return client.RunContext(ctx)
+}
+
+// handle processes every line read from the IRC connection: joining our
+// channel once registration completes, and forwarding channel PRIVMSGs.
+func (b *IRCBridge) handle(c *irc.Client, m *irc.Message) {
+	switch m.Command {
+	case "001": // RPL_WELCOME: registration is complete.
+		c.Write("JOIN " + b.cfg.Channel)
+
+	case "PRIVMSG":
+		if len(m.Params) != 2 || !strings.EqualFold(m.Params[0], b.cfg.Channel) {
+			return
+		}
+		// Room is left unset: the Gateway fills it in per local room this
+		// bridge is linked to.
+		b.recv <- store.Message{Username: m.Prefix.Name, Text: m.Trailing()}
+	}
+}
+
+// Send relays msg into the IRC channel as a PRIVMSG.
+func (b *IRCBridge) Send(msg store.Message) error {
+	b.mu.Lock()
+	client := b.client
+	b.mu.Unlock()
+
+	if client == nil {
+		return fmt.Errorf("irc bridge %s: not connected", b.cfg.BridgeName)
+	}
+
+	return client.WriteMessage(&irc.Message{
+		Command: "PRIVMSG",
+		Params:  []string{b.cfg.Channel, fmt.Sprintf("<%s> %s", msg.Username, msg.Text)},
+	})
+}
+
+// Receive returns the channel of messages read from the IRC channel.
+func (b *IRCBridge) Receive() <-chan store.Message { return b.recv }
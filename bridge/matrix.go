@@ -0,0 +1,95 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"maunium.net/go/mautrix"
+	"maunium.net/go/mautrix/event"
+	"maunium.net/go/mautrix/id"
+
+	"github.com/Akshaylinson/Realtime-chatapp-go/store"
+)
+
+// MatrixConfig configures a single Matrix room connection.
+type MatrixConfig struct {
+	// BridgeName identifies this bridge in config links and log lines. It
+	// is filled in from the enclosing LinkConfig's Name, not parsed from
+	// YAML directly.
+	BridgeName  string `yaml:"-"`
+	Homeserver  string `yaml:"homeserver"` // e.g. "https://matrix.org"
+	UserID      string `yaml:"user_id"`    // e.g. "@mybot:matrix.org"
+	AccessToken string `yaml:"access_token"`
+	RoomID      string `yaml:"room_id"` // e.g. "!abc123:matrix.org"
+}
+
+// MatrixBridge relays messages to and from a single room on one Matrix
+// homeserver, speaking the client-server API via maunium.net/go/mautrix.
+type MatrixBridge struct {
+	cfg MatrixConfig
+
+	mu     sync.Mutex
+	client *mautrix.Client
+
+	recv chan store.Message
+}
+
+// NewMatrixBridge creates a MatrixBridge that will log into cfg.Homeserver
+// and sync cfg.RoomID once started.
+func NewMatrixBridge(cfg MatrixConfig) *MatrixBridge {
+	return &MatrixBridge{cfg: cfg, recv: make(chan store.Message, 64)}
+}
+
+// Name returns the bridge's configured name.
+func (b *MatrixBridge) Name() string { return b.cfg.BridgeName }
+
+// BotNick returns this bridge's Matrix user ID.
+func (b *MatrixBridge) BotNick() string { return b.cfg.UserID }
+
+// Start logs into the homeserver and syncs room events until ctx is done.
+func (b *MatrixBridge) Start(ctx context.Context) error {
+	defer close(b.recv)
+
+	client, err := mautrix.NewClient(b.cfg.Homeserver, id.UserID(b.cfg.UserID), b.cfg.AccessToken)
+	if err != nil {
+		return fmt.Errorf("matrix bridge %s: connecting to %s: %w", b.cfg.BridgeName, b.cfg.Homeserver, err)
+	}
+
+	b.mu.Lock()
+	b.client = client
+	b.mu.Unlock()
+
+	roomID := id.RoomID(b.cfg.RoomID)
+	syncer := client.Syncer.(*mautrix.DefaultSyncer)
+	syncer.OnEventType(event.EventMessage, func(_ context.Context, evt *event.Event) {
+		if evt.RoomID != roomID || evt.Sender.String() == b.cfg.UserID {
+			return
+		}
+		b.recv <- store.Message{Username: evt.Sender.String(), Text: evt.Content.AsMessage().Body}
+	})
+
+	go func() {
+		<-ctx.Done()
+		client.StopSync()
+	}()
+
+	return client.SyncWithContext(ctx)
+}
+
+// Send relays msg into the Matrix room as an m.text message.
+func (b *MatrixBridge) Send(msg store.Message) error {
+	b.mu.Lock()
+	client := b.client
+	b.mu.Unlock()
+
+	if client == nil {
+		return fmt.Errorf("matrix bridge %s: not connected", b.cfg.BridgeName)
+	}
+
+	_, err := client.SendText(context.Background(), id.RoomID(b.cfg.RoomID), fmt.Sprintf("<%s> %s", msg.Username, msg.Text))
+	return err
+}
+
+// Receive returns the channel of messages read from the Matrix room.
+func (b *MatrixBridge) Receive() <-chan store.Message { return b.recv }
@@ -0,0 +1,125 @@
+package bridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Akshaylinson/Realtime-chatapp-go/store"
+)
+
+// WebhookConfig configures a generic incoming/outgoing webhook bridge: an
+// HTTP endpoint this server listens on for inbound messages, and a URL it
+// POSTs outbound messages to.
+type WebhookConfig struct {
+	// BridgeName identifies this bridge in config links and log lines. It
+	// is filled in from the enclosing LinkConfig's Name, not parsed from
+	// YAML directly.
+	BridgeName string `yaml:"-"`
+
+	// ListenAddr is the address the incoming webhook server binds, e.g.
+	// ":9090". Each webhook bridge runs its own listener so adapters can
+	// be added or removed independently of the main HTTP server.
+	ListenAddr string `yaml:"listen_addr"`
+
+	// ListenPath is the path POSTed to with an inbound message, as a JSON
+	// body of {"username": "...", "text": "..."}.
+	ListenPath string `yaml:"listen_path"`
+
+	// OutgoingURL is POSTed the same JSON body for every locally-accepted
+	// message relayed out through this bridge.
+	OutgoingURL string `yaml:"outgoing_url"`
+}
+
+// webhookPayload is the JSON body exchanged with both the incoming and
+// outgoing webhook endpoints.
+type webhookPayload struct {
+	Username string `json:"username"`
+	Text     string `json:"text"`
+}
+
+// WebhookBridge relays messages to and from a pair of plain HTTP webhooks,
+// for external networks that speak nothing more specific.
+type WebhookBridge struct {
+	cfg    WebhookConfig
+	client *http.Client
+	recv   chan store.Message
+}
+
+// NewWebhookBridge creates a WebhookBridge that will listen on
+// cfg.ListenAddr once started.
+func NewWebhookBridge(cfg WebhookConfig) *WebhookBridge {
+	return &WebhookBridge{cfg: cfg, client: &http.Client{}, recv: make(chan store.Message, 64)}
+}
+
+// Name returns the bridge's configured name.
+func (b *WebhookBridge) Name() string { return b.cfg.BridgeName }
+
+// BotNick is empty: plain webhooks have no identity of their own to loop
+// suppress against.
+func (b *WebhookBridge) BotNick() string { return "" }
+
+// Start runs the incoming webhook's HTTP server until ctx is done.
+func (b *WebhookBridge) Start(ctx context.Context) error {
+	defer close(b.recv)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(b.cfg.ListenPath, b.handleIncoming)
+	server := &http.Server{Addr: b.cfg.ListenAddr, Handler: mux}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.ListenAndServe() }()
+
+	select {
+	case <-ctx.Done():
+		return server.Shutdown(context.Background())
+	case err := <-errCh:
+		if err != nil && err != http.ErrServerClosed {
+			return fmt.Errorf("webhook bridge %s: %w", b.cfg.BridgeName, err)
+		}
+		return nil
+	}
+}
+
+// handleIncoming decodes a webhookPayload from the request body and
+// forwards it onto recv. Room is left unset: the Gateway fills it in for
+// the local room this bridge is linked to.
+func (b *WebhookBridge) handleIncoming(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var payload webhookPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "malformed body", http.StatusBadRequest)
+		return
+	}
+
+	b.recv <- store.Message{Username: payload.Username, Text: payload.Text}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Send POSTs msg to the configured outgoing webhook URL.
+func (b *WebhookBridge) Send(msg store.Message) error {
+	body, err := json.Marshal(webhookPayload{Username: msg.Username, Text: msg.Text})
+	if err != nil {
+		return err
+	}
+
+	resp, err := b.client.Post(b.cfg.OutgoingURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook bridge %s: %w", b.cfg.BridgeName, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook bridge %s: outgoing webhook returned %s", b.cfg.BridgeName, resp.Status)
+	}
+	return nil
+}
+
+// Receive returns the channel of messages posted to the incoming webhook.
+func (b *WebhookBridge) Receive() <-chan store.Message { return b.recv }
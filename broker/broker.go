@@ -0,0 +1,65 @@
+// Package broker fans chat messages out across server instances so that
+// replicas behind a load balancer all deliver to every room subscriber,
+// regardless of which instance a client is connected to.
+package broker
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Akshaylinson/Realtime-chatapp-go/store"
+)
+
+// Frame is an edit/delete/typing notification to fan out to other
+// instances verbatim. Unlike Message, a Frame is never persisted, so a
+// frame published while an instance is offline is simply missed rather
+// than replayed on reconnect.
+type Frame struct {
+	Room    string          `json:"room"`
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Broker publishes locally-accepted messages and frames to every other
+// instance and surfaces the ones they publish.
+type Broker interface {
+	// Publish fans msg out to every other subscribed instance.
+	Publish(ctx context.Context, msg store.Message) error
+
+	// Start begins listening for messages published by other instances and
+	// returns a channel of them. The channel is closed when ctx is done.
+	Start(ctx context.Context) (<-chan store.Message, error)
+
+	// PublishFrame fans frame out to every other subscribed instance.
+	PublishFrame(ctx context.Context, frame Frame) error
+
+	// StartFrames begins listening for frames published by other instances
+	// and returns a channel of them. The channel is closed when ctx is done.
+	StartFrames(ctx context.Context) (<-chan Frame, error)
+}
+
+// NoopBroker is the single-node default: nothing is published anywhere, and
+// nothing is ever received, since there are no other instances.
+type NoopBroker struct{}
+
+// Publish does nothing.
+func (NoopBroker) Publish(ctx context.Context, msg store.Message) error { return nil }
+
+// Start returns a channel that is closed immediately and never yields a
+// message.
+func (NoopBroker) Start(ctx context.Context) (<-chan store.Message, error) {
+	ch := make(chan store.Message)
+	close(ch)
+	return ch, nil
+}
+
+// PublishFrame does nothing.
+func (NoopBroker) PublishFrame(ctx context.Context, frame Frame) error { return nil }
+
+// StartFrames returns a channel that is closed immediately and never
+// yields a frame.
+func (NoopBroker) StartFrames(ctx context.Context) (<-chan Frame, error) {
+	ch := make(chan Frame)
+	close(ch)
+	return ch, nil
+}
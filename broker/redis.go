@@ -0,0 +1,190 @@
+package broker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Akshaylinson/Realtime-chatapp-go/store"
+)
+
+// roomChannelPrefix namespaces the Redis Pub/Sub channels used for message
+// fan-out, one per room: chat:room:<room>.
+const roomChannelPrefix = "chat:room:"
+
+// frameChannelPrefix namespaces the Redis Pub/Sub channels used for frame
+// fan-out (edits, deletes, typing), kept separate from roomChannelPrefix so
+// Start and StartFrames each only ever decode the envelope shape they sent.
+const frameChannelPrefix = "chat:frame:"
+
+// envelope wraps a message with the originID of the instance that accepted
+// it, so that instance can ignore its own re-published messages.
+type envelope struct {
+	OriginID string        `json:"origin_id"`
+	Message  store.Message `json:"message"`
+}
+
+// frameEnvelope is envelope's counterpart for Frame, published on
+// frameChannelPrefix instead of roomChannelPrefix.
+type frameEnvelope struct {
+	OriginID string `json:"origin_id"`
+	Frame    Frame  `json:"frame"`
+}
+
+// RedisBroker fans messages out to other instances via Redis Pub/Sub.
+type RedisBroker struct {
+	client   *redis.Client
+	originID string
+}
+
+// NewRedisBroker creates a RedisBroker connected to the server at addr. Each
+// instance gets a random originID so it can recognize and skip its own
+// published messages when they're echoed back by Redis.
+func NewRedisBroker(addr string) (*RedisBroker, error) {
+	originID, err := randomID()
+	if err != nil {
+		return nil, fmt.Errorf("generating broker origin id: %w", err)
+	}
+
+	return &RedisBroker{
+		client:   redis.NewClient(&redis.Options{Addr: addr}),
+		originID: originID,
+	}, nil
+}
+
+// Publish fans msg out to every other instance subscribed to its room.
+func (b *RedisBroker) Publish(ctx context.Context, msg store.Message) error {
+	data, err := json.Marshal(envelope{OriginID: b.originID, Message: msg})
+	if err != nil {
+		return fmt.Errorf("marshaling envelope: %w", err)
+	}
+
+	if err := b.client.Publish(ctx, roomChannelPrefix+msg.Room, data).Err(); err != nil {
+		return fmt.Errorf("publishing to %s: %w", msg.Room, err)
+	}
+	return nil
+}
+
+// Start subscribes to every room channel and forwards messages published by
+// other instances. Messages this instance published itself are dropped.
+func (b *RedisBroker) Start(ctx context.Context) (<-chan store.Message, error) {
+	sub := b.client.PSubscribe(ctx, roomChannelPrefix+"*")
+	if _, err := sub.Receive(ctx); err != nil {
+		return nil, fmt.Errorf("subscribing to %s*: %w", roomChannelPrefix, err)
+	}
+
+	out := make(chan store.Message)
+
+	go func() {
+		defer close(out)
+		defer sub.Close()
+
+		ch := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case raw, ok := <-ch:
+				if !ok {
+					return
+				}
+
+				var env envelope
+				if err := json.Unmarshal([]byte(raw.Payload), &env); err != nil {
+					log.Printf("Error decoding broker envelope: %v", err)
+					continue
+				}
+
+				if env.OriginID == b.originID {
+					// Our own publish, already delivered locally.
+					continue
+				}
+
+				select {
+				case out <- env.Message:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// PublishFrame fans frame out to every other instance subscribed to its
+// room.
+func (b *RedisBroker) PublishFrame(ctx context.Context, frame Frame) error {
+	data, err := json.Marshal(frameEnvelope{OriginID: b.originID, Frame: frame})
+	if err != nil {
+		return fmt.Errorf("marshaling frame envelope: %w", err)
+	}
+
+	if err := b.client.Publish(ctx, frameChannelPrefix+frame.Room, data).Err(); err != nil {
+		return fmt.Errorf("publishing frame to %s: %w", frame.Room, err)
+	}
+	return nil
+}
+
+// StartFrames subscribes to every frame channel and forwards frames
+// published by other instances. Frames this instance published itself are
+// dropped.
+func (b *RedisBroker) StartFrames(ctx context.Context) (<-chan Frame, error) {
+	sub := b.client.PSubscribe(ctx, frameChannelPrefix+"*")
+	if _, err := sub.Receive(ctx); err != nil {
+		return nil, fmt.Errorf("subscribing to %s*: %w", frameChannelPrefix, err)
+	}
+
+	out := make(chan Frame)
+
+	go func() {
+		defer close(out)
+		defer sub.Close()
+
+		ch := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case raw, ok := <-ch:
+				if !ok {
+					return
+				}
+
+				var env frameEnvelope
+				if err := json.Unmarshal([]byte(raw.Payload), &env); err != nil {
+					log.Printf("Error decoding broker frame envelope: %v", err)
+					continue
+				}
+
+				if env.OriginID == b.originID {
+					// Our own publish, already delivered locally.
+					continue
+				}
+
+				select {
+				case out <- env.Frame:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func randomID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
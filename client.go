@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/Akshaylinson/Realtime-chatapp-go/store"
+)
+
+const (
+	// Time allowed to write a message to the peer.
+	writeWait = 10 * time.Second
+
+	// Time allowed to read the next pong message from the peer.
+	pongWait = 60 * time.Second
+
+	// Send pings to the peer with this period. Must be less than pongWait.
+	pingPeriod = (pongWait * 9) / 10
+
+	// Maximum message size allowed from the peer.
+	maxMessageSize = 512
+
+	// authTimeout is how long a newly connected client has to send its
+	// auth_request frame before the connection is dropped.
+	authTimeout = 10 * time.Second
+
+	// typingDebounce is the minimum interval between typing frames the hub
+	// will forward for a single client, so a burst of keystrokes doesn't
+	// flood every other subscriber.
+	typingDebounce = 3 * time.Second
+)
+
+// defaultRoom is used when a chat message or subscribe request doesn't name
+// a room explicitly.
+const defaultRoom = "general"
+
+// Client is a middleman between the Hub and a single websocket connection.
+type Client struct {
+	hub      *Hub
+	conn     *websocket.Conn
+	Username string
+	UserID   string
+
+	// send is a buffered channel of outbound messages to this client.
+	send chan []byte
+
+	// lastTyping debounces outgoing typing frames; readPump is the only
+	// goroutine that touches it, so it needs no lock.
+	lastTyping time.Time
+}
+
+// sendFrame encodes kind/payload as a Frame and queues it on c.send,
+// dropping it if the client's buffer is full rather than blocking.
+func (c *Client) sendFrame(kind string, payload interface{}) {
+	data, err := encodeFrame(kind, payload)
+	if err != nil {
+		log.Printf("Error encoding frame: %v", err)
+		return
+	}
+	select {
+	case c.send <- data:
+	default:
+	}
+}
+
+// sendRoomHistory pushes the recent history of room to the client's send
+// channel, each message wrapped in a history Frame, so it can render a
+// scrollback without polling /messages.
+func (c *Client) sendRoomHistory(room string) {
+	history, err := GetMessages(room, 100, 0)
+	if err != nil {
+		log.Printf("Error loading history for room %q: %v", room, err)
+		return
+	}
+
+	for _, msg := range history {
+		c.sendFrame(frameHistory, msg)
+	}
+}
+
+// readPump pumps frames from the websocket connection to the hub.
+//
+// The caller must start readPump as its own goroutine. It exits, and closes
+// the connection, when the peer disconnects or a read error occurs. rooms
+// are auto-joined once the client authenticates.
+func (c *Client) readPump(rooms []string) {
+	defer func() {
+		if c.Username != "" {
+			c.hub.unregister <- c
+		}
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	if !c.authenticate(rooms) {
+		// authenticate failed before the client ever reached
+		// c.hub.register, so Hub.Run's unregister case will never run for
+		// it and never close c.send; close it here instead so writePump
+		// exits immediately rather than idling until its ping ticker fires
+		// against an already-closed connection.
+		close(c.send)
+		return
+	}
+
+	for {
+		var frame Frame
+		if err := c.conn.ReadJSON(&frame); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				log.Printf("WebSocket read error for %s: %v", c.Username, err)
+			}
+			break
+		}
+
+		c.handleFrame(frame)
+	}
+}
+
+// authenticate blocks for the client's first frame, which must be an
+// auth_request, and answers with an auth_response. On success it registers
+// the client with the hub and subscribes it to rooms. It returns false if
+// the connection should be closed.
+func (c *Client) authenticate(rooms []string) bool {
+	c.conn.SetReadDeadline(time.Now().Add(authTimeout))
+
+	var frame Frame
+	if err := c.conn.ReadJSON(&frame); err != nil {
+		return false
+	}
+	if frame.Kind != frameAuthRequest {
+		c.sendFrame(frameError, errorPayload{Message: "first frame must be auth_request"})
+		return false
+	}
+
+	var req authRequestPayload
+	if err := json.Unmarshal(frame.Payload, &req); err != nil {
+		c.sendFrame(frameError, errorPayload{Message: "malformed auth_request payload"})
+		return false
+	}
+
+	userID, ok := tokenStore.Authenticate(req.Nickname, req.Token)
+	if !ok {
+		c.sendFrame(frameAuthResponse, authResponsePayload{Success: false})
+		return false
+	}
+
+	c.Username = req.Nickname
+	c.UserID = userID
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+
+	c.sendFrame(frameAuthResponse, authResponsePayload{Success: true, UserID: userID})
+
+	c.hub.register <- c
+	for _, room := range rooms {
+		c.hub.Subscribe(c, room)
+		c.sendRoomHistory(room)
+	}
+	return true
+}
+
+// handleFrame dispatches a single post-auth frame by kind.
+func (c *Client) handleFrame(frame Frame) {
+	switch frame.Kind {
+	case frameSubscribe:
+		var payload subscribePayload
+		if err := json.Unmarshal(frame.Payload, &payload); err != nil {
+			c.sendFrame(frameError, errorPayload{Message: "malformed subscribe payload"})
+			return
+		}
+		room := payload.Room
+		if room == "" {
+			room = defaultRoom
+		}
+		c.hub.Subscribe(c, room)
+		c.sendRoomHistory(room)
+
+	case frameUnsubscribe:
+		var payload subscribePayload
+		if err := json.Unmarshal(frame.Payload, &payload); err != nil {
+			c.sendFrame(frameError, errorPayload{Message: "malformed unsubscribe payload"})
+			return
+		}
+		if payload.Room != "" {
+			c.hub.Unsubscribe(c, payload.Room)
+		}
+
+	case frameChatMessage:
+		var payload chatMessagePayload
+		if err := json.Unmarshal(frame.Payload, &payload); err != nil {
+			c.sendFrame(frameError, errorPayload{Message: "malformed chat_message payload"})
+			return
+		}
+		room := payload.Room
+		if room == "" {
+			room = defaultRoom
+		}
+
+		msg, err := SaveMessage(room, c.Username, c.UserID, payload.Text)
+		if err != nil {
+			log.Printf("Error saving message: %v", err)
+			c.sendFrame(frameError, errorPayload{Message: "failed to save message"})
+			return
+		}
+		c.hub.Broadcast(msg)
+		RelayToBridges(msg)
+
+	case frameEditMessage:
+		var payload editMessagePayload
+		if err := json.Unmarshal(frame.Payload, &payload); err != nil {
+			c.sendFrame(frameError, errorPayload{Message: "malformed edit_message payload"})
+			return
+		}
+
+		msg, err := EditMessage(payload.Room, payload.ID, c.UserID, payload.Text)
+		if err != nil {
+			c.sendFrame(frameError, errorPayload{Message: editErrorMessage(err)})
+			return
+		}
+		c.hub.BroadcastFrame(payload.Room, frameEditMessage, msg)
+
+	case frameDeleteMessage:
+		var payload deleteMessagePayload
+		if err := json.Unmarshal(frame.Payload, &payload); err != nil {
+			c.sendFrame(frameError, errorPayload{Message: "malformed delete_message payload"})
+			return
+		}
+
+		if err := DeleteMessage(payload.Room, payload.ID, c.UserID); err != nil {
+			c.sendFrame(frameError, errorPayload{Message: editErrorMessage(err)})
+			return
+		}
+		c.hub.BroadcastFrame(payload.Room, frameDeleteMessage, payload)
+
+	case frameTyping:
+		var payload typingPayload
+		if err := json.Unmarshal(frame.Payload, &payload); err != nil {
+			return
+		}
+		if time.Since(c.lastTyping) < typingDebounce {
+			return
+		}
+		c.lastTyping = time.Now()
+
+		payload.Username = c.Username
+		c.hub.BroadcastFrame(payload.Room, frameTyping, payload)
+
+	default:
+		c.sendFrame(frameError, errorPayload{Message: "unknown frame kind: " + frame.Kind})
+	}
+}
+
+// editErrorMessage maps a store error from Edit/Delete to client-facing
+// text, without leaking internal wrapping detail.
+func editErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, store.ErrForbidden):
+		return "you can only edit or delete your own messages"
+	case errors.Is(err, store.ErrNotFound):
+		return "message not found"
+	default:
+		return "failed to update message"
+	}
+}
+
+// writePump pumps messages from the hub to the websocket connection.
+//
+// A goroutine running writePump is started for each connection. The
+// application ensures that there is at most one writer to a connection by
+// executing all writes from this goroutine.
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				// The hub closed the channel.
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Command chat-tui is a terminal client for the chat server: a scrollable
+// per-room viewport, a tab bar for switching rooms, and a textarea input
+// with /join, /leave, /rooms and /quit slash commands. It exists alongside
+// the browser UI for headless or SSH-only users.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/log"
+)
+
+func main() {
+	addr := flag.String("addr", "ws://localhost:8080/ws", "chat server WebSocket URL")
+	nickname := flag.String("nick", "", "nickname to authenticate as (required)")
+	token := flag.String("token", "", "auth token for --nick, if the server requires one")
+	rooms := flag.String("rooms", "general", "comma-separated rooms to join on connect")
+	debugLog := flag.String("debug-log", "", "write debug logs to this file instead of discarding them")
+	flag.Parse()
+
+	if *nickname == "" {
+		fmt.Fprintln(os.Stderr, "chat-tui: -nick is required")
+		os.Exit(1)
+	}
+
+	// Debug logging goes to a file, never stdout/stderr: writing there
+	// would corrupt the alt-screen TUI. With no -debug-log, it's discarded.
+	var logger *log.Logger
+	if *debugLog != "" {
+		f, err := os.OpenFile(*debugLog, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "chat-tui: opening debug log: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		logger = log.NewWithOptions(f, log.Options{ReportTimestamp: true, Prefix: "chat-tui"})
+	} else {
+		logger = log.NewWithOptions(io.Discard, log.Options{})
+	}
+
+	roomList := splitRooms(*rooms)
+	c, err := dial(withRoomsQuery(*addr, roomList), *nickname, *token)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chat-tui: %v\n", err)
+		os.Exit(1)
+	}
+
+	m := newModel(c, *nickname, roomList, logger)
+	p := tea.NewProgram(m, tea.WithAltScreen())
+
+	go c.readLoop(p)
+
+	if _, err := p.Run(); err != nil {
+		c.close()
+		fmt.Fprintf(os.Stderr, "chat-tui: %v\n", err)
+		os.Exit(1)
+	}
+	c.close()
+}
+
+// withRoomsQuery appends a "rooms" query parameter to addr so the server
+// auto-subscribes and sends history for rooms on connect, the same way the
+// browser client does.
+func withRoomsQuery(addr string, rooms []string) string {
+	if len(rooms) == 0 {
+		return addr
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		return addr
+	}
+	q := u.Query()
+	q.Set("rooms", strings.Join(rooms, ","))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// splitRooms parses a comma-separated room list, trimming whitespace and
+// dropping empty entries.
+func splitRooms(raw string) []string {
+	var rooms []string
+	for _, room := range strings.Split(raw, ",") {
+		room = strings.TrimSpace(room)
+		if room != "" {
+			rooms = append(rooms, room)
+		}
+	}
+	return rooms
+}
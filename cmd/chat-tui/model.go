@@ -0,0 +1,407 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/log"
+)
+
+// tabBarHeight and inputHeight size the chrome around the viewport; the
+// viewport itself gets whatever's left of the terminal.
+const (
+	tabBarHeight = 1
+	inputHeight  = 3
+)
+
+var (
+	activeTabStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("230")).Background(lipgloss.Color("63")).Padding(0, 1)
+	inactiveTabStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Padding(0, 1)
+	systemStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Italic(true)
+	usernameStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("86"))
+	errorStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("204"))
+)
+
+// model is the Bubble Tea model for the whole client: a tab bar of joined
+// rooms, a scrollable message viewport for the active one, and a textarea
+// for composing the next message or slash command.
+type model struct {
+	c        *conn
+	nickname string
+	logger   *log.Logger
+
+	rooms  []string            // joined rooms, in tab-bar order
+	active int                 // index into rooms of the room shown in the viewport
+	buffer map[string][]string // rendered lines per room
+
+	viewport viewport.Model
+	textarea textarea.Model
+
+	width, height int
+	ready         bool
+
+	statusErr string // last error shown under the input box, if any
+}
+
+// newModel builds the initial model for a client already authenticated and
+// subscribed to rooms via c.
+func newModel(c *conn, nickname string, rooms []string, logger *log.Logger) model {
+	ta := textarea.New()
+	ta.Placeholder = "Message... (/join, /leave, /rooms, /quit)"
+	ta.Prompt = "> "
+	ta.CharLimit = 0
+	ta.ShowLineNumbers = false
+	ta.SetHeight(inputHeight - 2)
+	ta.Focus()
+
+	buffer := make(map[string][]string, len(rooms))
+	for _, room := range rooms {
+		buffer[room] = nil
+	}
+
+	return model{
+		c:        c,
+		nickname: nickname,
+		logger:   logger,
+		rooms:    rooms,
+		buffer:   buffer,
+		textarea: ta,
+	}
+}
+
+// Init starts the textarea's cursor blink; the WebSocket read loop is
+// already running in its own goroutine, forwarding frames via p.Send.
+func (m model) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		return m.resize(msg), nil
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+
+	case incomingFrameMsg:
+		return m.handleFrame(msg.frame), nil
+
+	case connClosedMsg:
+		if msg.err != nil {
+			m.statusErr = fmt.Sprintf("connection closed: %v", msg.err)
+		}
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	m.textarea, cmd = m.textarea.Update(msg)
+	return m, cmd
+}
+
+// resize lays the viewport and textarea out for the new terminal size.
+func (m model) resize(msg tea.WindowSizeMsg) model {
+	m.width, m.height = msg.Width, msg.Height
+
+	viewportHeight := msg.Height - tabBarHeight - inputHeight
+	if viewportHeight < 1 {
+		viewportHeight = 1
+	}
+
+	if !m.ready {
+		m.viewport = viewport.New(msg.Width, viewportHeight)
+		m.ready = true
+	} else {
+		m.viewport.Width = msg.Width
+		m.viewport.Height = viewportHeight
+	}
+	m.textarea.SetWidth(msg.Width)
+	m.renderActiveRoom()
+	return m
+}
+
+// handleKey processes a single keypress, intercepting the ones this model
+// owns (submit, quit, room switching) before falling through to the
+// textarea for everything else.
+func (m model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		if m.c != nil {
+			m.c.close()
+		}
+		return m, tea.Quit
+
+	case "enter":
+		return m.submit()
+
+	case "ctrl+right":
+		return m.switchTab(1), nil
+
+	case "ctrl+left":
+		return m.switchTab(-1), nil
+	}
+
+	var cmd tea.Cmd
+	m.textarea, cmd = m.textarea.Update(msg)
+	return m, cmd
+}
+
+// switchTab moves the active room by delta, wrapping around, and
+// re-renders the viewport for it.
+func (m model) switchTab(delta int) model {
+	if len(m.rooms) == 0 {
+		return m
+	}
+	m.active = (m.active + delta + len(m.rooms)) % len(m.rooms)
+	m.renderActiveRoom()
+	return m
+}
+
+// submit handles the textarea's current value on Enter: a slash command if
+// it starts with '/', otherwise a chat message to the active room.
+func (m model) submit() (tea.Model, tea.Cmd) {
+	text := strings.TrimSpace(m.textarea.Value())
+	m.textarea.Reset()
+	if text == "" {
+		return m, nil
+	}
+
+	if strings.HasPrefix(text, "/") {
+		return m.handleCommand(text)
+	}
+
+	if len(m.rooms) == 0 {
+		m.statusErr = "not subscribed to any room; use /join <room>"
+		return m, nil
+	}
+
+	room := m.rooms[m.active]
+	if err := m.c.sendChat(room, text); err != nil {
+		m.statusErr = fmt.Sprintf("send failed: %v", err)
+	}
+	return m, nil
+}
+
+// handleCommand dispatches a leading-slash line to /join, /leave, /rooms or
+// /quit.
+func (m model) handleCommand(text string) (tea.Model, tea.Cmd) {
+	fields := strings.Fields(text)
+	cmd := fields[0]
+	var arg string
+	if len(fields) > 1 {
+		arg = fields[1]
+	}
+
+	switch cmd {
+	case "/join":
+		if arg == "" {
+			m.statusErr = "usage: /join <room>"
+			return m, nil
+		}
+		return m.join(arg), nil
+
+	case "/leave":
+		room := arg
+		if room == "" && len(m.rooms) > 0 {
+			room = m.rooms[m.active]
+		}
+		return m.leave(room), nil
+
+	case "/rooms":
+		if len(m.rooms) == 0 {
+			m.statusErr = "not subscribed to any room; use /join <room>"
+			return m, nil
+		}
+		m.appendSystem(m.rooms[m.active], "rooms: "+strings.Join(m.rooms, ", "))
+		m.renderActiveRoom()
+		return m, nil
+
+	case "/quit":
+		if m.c != nil {
+			m.c.close()
+		}
+		return m, tea.Quit
+
+	default:
+		m.statusErr = "unknown command: " + cmd
+		return m, nil
+	}
+}
+
+// join subscribes to room, adding it as a new tab and switching to it.
+func (m model) join(room string) model {
+	for i, r := range m.rooms {
+		if r == room {
+			m.active = i
+			m.renderActiveRoom()
+			return m
+		}
+	}
+
+	if err := m.c.subscribe(room); err != nil {
+		m.statusErr = fmt.Sprintf("join failed: %v", err)
+		return m
+	}
+
+	m.rooms = append(m.rooms, room)
+	m.buffer[room] = nil
+	m.active = len(m.rooms) - 1
+	m.renderActiveRoom()
+	return m
+}
+
+// leave unsubscribes from room, removing its tab and switching to a
+// neighbour.
+func (m model) leave(room string) model {
+	index := -1
+	for i, r := range m.rooms {
+		if r == room {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		m.statusErr = "not in room: " + room
+		return m
+	}
+
+	if err := m.c.unsubscribe(room); err != nil {
+		m.statusErr = fmt.Sprintf("leave failed: %v", err)
+		return m
+	}
+
+	m.rooms = append(m.rooms[:index], m.rooms[index+1:]...)
+	delete(m.buffer, room)
+	if m.active >= len(m.rooms) {
+		m.active = len(m.rooms) - 1
+	}
+	if m.active < 0 {
+		m.active = 0
+	}
+	m.renderActiveRoom()
+	return m
+}
+
+// handleFrame updates the relevant room buffer for an incoming Frame and
+// re-renders the viewport if that room is the one currently shown.
+func (m model) handleFrame(frame Frame) model {
+	switch frame.Kind {
+	case frameHistory, frameChatMessage:
+		var msg message
+		if err := json.Unmarshal(frame.Payload, &msg); err != nil {
+			m.logger.Error("malformed message frame", "err", err)
+			return m
+		}
+		m.appendChat(msg)
+
+	case frameEditMessage:
+		var msg message
+		if err := json.Unmarshal(frame.Payload, &msg); err != nil {
+			m.logger.Error("malformed edit_message frame", "err", err)
+			return m
+		}
+		m.appendSystem(msg.Room, fmt.Sprintf("%s edited a message: %s", msg.Username, msg.Text))
+
+	case frameDeleteMessage:
+		var payload struct {
+			Room string `json:"room"`
+			ID   int    `json:"id"`
+		}
+		if err := json.Unmarshal(frame.Payload, &payload); err != nil {
+			m.logger.Error("malformed delete_message frame", "err", err)
+			return m
+		}
+		m.appendSystem(payload.Room, "a message was deleted")
+
+	case framePresence:
+		var payload presencePayload
+		if err := json.Unmarshal(frame.Payload, &payload); err != nil {
+			m.logger.Error("malformed presence frame", "err", err)
+			return m
+		}
+		verb := "left"
+		if payload.Online {
+			verb = "joined"
+		}
+		m.appendSystem(payload.Room, fmt.Sprintf("%s %s", payload.Username, verb))
+
+	case frameTyping:
+		var payload typingPayload
+		if err := json.Unmarshal(frame.Payload, &payload); err != nil {
+			m.logger.Error("malformed typing frame", "err", err)
+			return m
+		}
+		m.logger.Debug("typing", "room", payload.Room, "username", payload.Username)
+
+	case frameError:
+		var payload errorPayload
+		if err := json.Unmarshal(frame.Payload, &payload); err != nil {
+			m.logger.Error("malformed error frame", "err", err)
+			return m
+		}
+		m.statusErr = payload.Message
+
+	default:
+		m.logger.Warn("unhandled frame kind", "kind", frame.Kind)
+	}
+
+	m.renderActiveRoom()
+	return m
+}
+
+// appendChat formats and appends a chat message to its room's buffer.
+func (m model) appendChat(msg message) {
+	if _, ok := m.buffer[msg.Room]; !ok {
+		return // a message for a room we're not subscribed to; ignore.
+	}
+	line := fmt.Sprintf("%s: %s", usernameStyle.Render(msg.Username), msg.Text)
+	if msg.Deleted {
+		line = systemStyle.Render(fmt.Sprintf("%s: (message deleted)", msg.Username))
+	}
+	m.buffer[msg.Room] = append(m.buffer[msg.Room], line)
+}
+
+// appendSystem appends an italicized system notice to room's buffer.
+func (m model) appendSystem(room, text string) {
+	if _, ok := m.buffer[room]; !ok {
+		return
+	}
+	m.buffer[room] = append(m.buffer[room], systemStyle.Render("* "+text))
+}
+
+// renderActiveRoom pushes the active room's buffer into the viewport and
+// scrolls to the bottom.
+func (m *model) renderActiveRoom() {
+	if !m.ready || len(m.rooms) == 0 {
+		return
+	}
+	room := m.rooms[m.active]
+	m.viewport.SetContent(strings.Join(m.buffer[room], "\n"))
+	m.viewport.GotoBottom()
+}
+
+func (m model) View() string {
+	if !m.ready {
+		return "connecting...\n"
+	}
+
+	var tabs strings.Builder
+	for i, room := range m.rooms {
+		if i == m.active {
+			tabs.WriteString(activeTabStyle.Render(room))
+		} else {
+			tabs.WriteString(inactiveTabStyle.Render(room))
+		}
+	}
+
+	status := ""
+	if m.statusErr != "" {
+		status = errorStyle.Render(m.statusErr)
+	}
+
+	return fmt.Sprintf("%s\n%s\n%s\n%s", tabs.String(), m.viewport.View(), m.textarea.View(), status)
+}
@@ -0,0 +1,90 @@
+package main
+
+import "encoding/json"
+
+// Frame is the envelope for every message exchanged over the WebSocket, in
+// both directions. It mirrors the server's protocol.go exactly: Kind
+// discriminates how Payload should be decoded.
+type Frame struct {
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Frame kinds, matching the server's protocol.go.
+const (
+	frameAuthRequest   = "auth_request"
+	frameAuthResponse  = "auth_response"
+	frameSubscribe     = "subscribe"
+	frameUnsubscribe   = "unsubscribe"
+	frameChatMessage   = "chat_message"
+	frameEditMessage   = "edit_message"
+	frameDeleteMessage = "delete_message"
+	frameTyping        = "typing"
+	framePresence      = "presence"
+	frameHistory       = "history"
+	frameError         = "error"
+)
+
+// authRequestPayload is the first frame this client must send: its
+// nickname and a token to prove it.
+type authRequestPayload struct {
+	Nickname string `json:"nickname"`
+	Token    string `json:"token"`
+}
+
+// authResponsePayload answers an auth_request. UserID is set only when
+// Success is true.
+type authResponsePayload struct {
+	Success bool   `json:"success"`
+	UserID  string `json:"user_id,omitempty"`
+}
+
+// subscribePayload names the room to join or leave.
+type subscribePayload struct {
+	Room string `json:"room"`
+}
+
+// chatMessagePayload is a new message this client wants to send.
+type chatMessagePayload struct {
+	Room string `json:"room"`
+	Text string `json:"text"`
+}
+
+// message is a single chat message, scoped to a room. It mirrors the
+// server's store.Message, trimmed to the fields the TUI renders.
+type message struct {
+	ID       int    `json:"id"`
+	Room     string `json:"room"`
+	Username string `json:"username"`
+	Text     string `json:"text"`
+	Deleted  bool   `json:"deleted,omitempty"`
+}
+
+// typingPayload announces that a user is composing a message in room.
+type typingPayload struct {
+	Room     string `json:"room"`
+	Username string `json:"username"`
+}
+
+// presencePayload announces a user joining or leaving room.
+type presencePayload struct {
+	Room     string `json:"room"`
+	Username string `json:"username"`
+	Online   bool   `json:"online"`
+}
+
+// errorPayload carries a human-readable problem description from the
+// server.
+type errorPayload struct {
+	Message string `json:"message"`
+}
+
+// encodeFrame marshals payload and wraps it in a Frame of the given kind,
+// ready to write to the WebSocket connection.
+func encodeFrame(kind string, payload interface{}) ([]byte, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Frame{Kind: kind, Payload: raw})
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gorilla/websocket"
+)
+
+// conn wraps the WebSocket connection to the chat server, authenticated and
+// ready to subscribe to rooms and send frames.
+type conn struct {
+	ws     *websocket.Conn
+	userID string
+}
+
+// dial connects to addr and authenticates as nickname, returning once an
+// auth_response has been received. addr should already carry a "rooms"
+// query parameter (see serveWs) for the server to auto-subscribe and send
+// history for; dial itself only sends further /join or /leave subscribe
+// frames. It blocks the caller, so it must run before the Bubble Tea
+// program starts, not inside Update.
+func dial(addr, nickname, token string) (*conn, error) {
+	ws, _, err := websocket.DefaultDialer.Dial(addr, nil)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+
+	auth, err := encodeFrame(frameAuthRequest, authRequestPayload{Nickname: nickname, Token: token})
+	if err != nil {
+		ws.Close()
+		return nil, err
+	}
+	if err := ws.WriteMessage(websocket.TextMessage, auth); err != nil {
+		ws.Close()
+		return nil, fmt.Errorf("sending auth_request: %w", err)
+	}
+
+	var frame Frame
+	if err := ws.ReadJSON(&frame); err != nil {
+		ws.Close()
+		return nil, fmt.Errorf("reading auth_response: %w", err)
+	}
+	if frame.Kind != frameAuthResponse {
+		ws.Close()
+		return nil, fmt.Errorf("expected auth_response, got %q", frame.Kind)
+	}
+
+	var resp authResponsePayload
+	if err := json.Unmarshal(frame.Payload, &resp); err != nil {
+		ws.Close()
+		return nil, fmt.Errorf("malformed auth_response: %w", err)
+	}
+	if !resp.Success {
+		ws.Close()
+		return nil, fmt.Errorf("authentication rejected for %q", nickname)
+	}
+
+	return &conn{ws: ws, userID: resp.UserID}, nil
+}
+
+// incomingFrameMsg is a tea.Msg wrapping a Frame read from the server.
+type incomingFrameMsg struct{ frame Frame }
+
+// connClosedMsg reports that the read loop ended, with the error that
+// caused it, or nil on a clean close.
+type connClosedMsg struct{ err error }
+
+// readLoop forwards every frame read from c as a tea.Msg via p.Send, until
+// the connection closes. It must run in its own goroutine so the UI stays
+// responsive while waiting on the socket.
+func (c *conn) readLoop(p *tea.Program) {
+	for {
+		var frame Frame
+		if err := c.ws.ReadJSON(&frame); err != nil {
+			p.Send(connClosedMsg{err: err})
+			return
+		}
+		p.Send(incomingFrameMsg{frame: frame})
+	}
+}
+
+// send encodes kind/payload as a Frame and writes it to the connection.
+func (c *conn) send(kind string, payload interface{}) error {
+	data, err := encodeFrame(kind, payload)
+	if err != nil {
+		return err
+	}
+	return c.ws.WriteMessage(websocket.TextMessage, data)
+}
+
+// sendChat sends text as a new chat_message in room.
+func (c *conn) sendChat(room, text string) error {
+	return c.send(frameChatMessage, chatMessagePayload{Room: room, Text: text})
+}
+
+// subscribe joins room.
+func (c *conn) subscribe(room string) error {
+	return c.send(frameSubscribe, subscribePayload{Room: room})
+}
+
+// unsubscribe leaves room.
+func (c *conn) unsubscribe(room string) error {
+	return c.send(frameUnsubscribe, subscribePayload{Room: room})
+}
+
+// close shuts down the connection.
+func (c *conn) close() error {
+	return c.ws.Close()
+}
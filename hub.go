@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/Akshaylinson/Realtime-chatapp-go/broker"
+)
+
+// clientSendBuffer is the capacity of each Client's outbound channel. A
+// client that can't keep up with this much backlog is disconnected.
+const clientSendBuffer = 256
+
+// subscription is a request to join or leave a room.
+type subscription struct {
+	client *Client
+	room   string
+}
+
+// Hub maintains the set of active clients, the rooms they've joined, and
+// broadcasts messages to the right subscribers. All mutations happen on the
+// single goroutine running Run, so none of this state needs its own lock.
+type Hub struct {
+	clients map[*Client]bool
+
+	// rooms indexes clients by the room(s) they've subscribed to.
+	rooms map[string]map[*Client]bool
+
+	// clientRooms is the inverse index, used to clean up on disconnect.
+	clientRooms map[*Client]map[string]bool
+
+	// Inbound messages to route to msg.Room's subscribers.
+	broadcast chan Message
+
+	// Messages fanned in from other server instances via broker, to be
+	// delivered locally without being published back out.
+	remote chan Message
+
+	// frames carries room-scoped frames that are never persisted: edits,
+	// deletes, typing, and presence. Edits, deletes and typing are also
+	// replicated to other instances via broker.PublishFrame; presence is
+	// local-only since it's derived from this instance's own client set.
+	frames chan roomFrame
+
+	// broker replicates locally-accepted messages to other instances. It
+	// defaults to broker.NoopBroker{}, the single-node no-op.
+	broker broker.Broker
+
+	// Register/unregister requests from clients.
+	register   chan *Client
+	unregister chan *Client
+
+	// Room (un)subscribe requests.
+	subscribe   chan subscription
+	unsubscribe chan subscription
+
+	// count and roomStats answer synchronous queries about hub state.
+	count     chan chan int
+	roomStats chan chan map[string]int
+}
+
+// roomFrame is an already-encoded Frame bound for every subscriber of room.
+type roomFrame struct {
+	room string
+	data []byte
+}
+
+// NewHub creates a Hub with its channels ready to use. Callers must run
+// Hub.Run in its own goroutine before clients can register.
+func NewHub() *Hub {
+	return &Hub{
+		clients:     make(map[*Client]bool),
+		rooms:       make(map[string]map[*Client]bool),
+		clientRooms: make(map[*Client]map[string]bool),
+		broadcast:   make(chan Message),
+		remote:      make(chan Message),
+		frames:      make(chan roomFrame),
+		broker:      broker.NoopBroker{},
+		register:    make(chan *Client),
+		unregister:  make(chan *Client),
+		subscribe:   make(chan subscription),
+		unsubscribe: make(chan subscription),
+		count:       make(chan chan int),
+		roomStats:   make(chan chan map[string]int),
+	}
+}
+
+// Run serializes all registrations, subscriptions and broadcasts through a
+// single goroutine so the client and room maps never need their own lock.
+func (h *Hub) Run() {
+	for {
+		select {
+		case client := <-h.register:
+			h.clients[client] = true
+			log.Printf("Client connected: %s (Total clients: %d)", client.Username, len(h.clients))
+
+		case client := <-h.unregister:
+			if _, ok := h.clients[client]; ok {
+				delete(h.clients, client)
+				for room := range h.clientRooms[client] {
+					h.announcePresence(room, client.Username, false)
+					delete(h.rooms[room], client)
+				}
+				delete(h.clientRooms, client)
+				close(client.send)
+				log.Printf("Client disconnected: %s (Total clients: %d)", client.Username, len(h.clients))
+			}
+
+		case sub := <-h.subscribe:
+			if h.rooms[sub.room] == nil {
+				h.rooms[sub.room] = make(map[*Client]bool)
+			}
+			h.rooms[sub.room][sub.client] = true
+
+			if h.clientRooms[sub.client] == nil {
+				h.clientRooms[sub.client] = make(map[string]bool)
+			}
+			h.clientRooms[sub.client][sub.room] = true
+
+			h.announcePresence(sub.room, sub.client.Username, true)
+
+		case sub := <-h.unsubscribe:
+			delete(h.rooms[sub.room], sub.client)
+			delete(h.clientRooms[sub.client], sub.room)
+			h.announcePresence(sub.room, sub.client.Username, false)
+
+		case rf := <-h.frames:
+			h.deliverRaw(rf.room, rf.data)
+
+		case msg := <-h.broadcast:
+			h.deliver(msg)
+
+			go func() {
+				if err := h.broker.Publish(context.Background(), msg); err != nil {
+					log.Printf("Error publishing to broker: %v", err)
+				}
+			}()
+
+		case msg := <-h.remote:
+			// Already published by the instance that accepted it; just
+			// deliver to our local subscribers.
+			h.deliver(msg)
+
+		case reply := <-h.count:
+			reply <- len(h.clients)
+
+		case reply := <-h.roomStats:
+			stats := make(map[string]int, len(h.rooms))
+			for room, members := range h.rooms {
+				stats[room] = len(members)
+			}
+			reply <- stats
+		}
+	}
+}
+
+// deliver wraps msg in a chat_message Frame and sends it to every client
+// subscribed to msg.Room.
+func (h *Hub) deliver(msg Message) {
+	data, err := encodeFrame(frameChatMessage, msg)
+	if err != nil {
+		log.Printf("Error encoding frame: %v", err)
+		return
+	}
+	h.deliverRaw(msg.Room, data)
+}
+
+// deliverRaw sends an already-encoded frame to every client subscribed to
+// room, dropping slow consumers rather than blocking the hub goroutine on
+// them.
+func (h *Hub) deliverRaw(room string, data []byte) {
+	for client := range h.rooms[room] {
+		select {
+		case client.send <- data:
+		default:
+			close(client.send)
+			delete(h.clients, client)
+			for r := range h.clientRooms[client] {
+				delete(h.rooms[r], client)
+			}
+			delete(h.clientRooms, client)
+		}
+	}
+}
+
+// announcePresence fans out a presence Frame for username joining or
+// leaving room.
+func (h *Hub) announcePresence(room, username string, online bool) {
+	data, err := encodeFrame(framePresence, presencePayload{Room: room, Username: username, Online: online})
+	if err != nil {
+		log.Printf("Error encoding frame: %v", err)
+		return
+	}
+	h.deliverRaw(room, data)
+}
+
+// Broadcast queues msg for delivery to every client subscribed to msg.Room,
+// then publishes it to the broker for other server instances to pick up.
+func (h *Hub) Broadcast(msg Message) {
+	h.broadcast <- msg
+}
+
+// BroadcastRemote delivers msg, accepted by another server instance, to this
+// instance's local subscribers without re-publishing it to the broker.
+func (h *Hub) BroadcastRemote(msg Message) {
+	h.remote <- msg
+}
+
+// BroadcastFrame fans a kind/payload Frame out to room's subscribers on
+// this instance, and publishes it to the broker so sibling instances get
+// it too. Used for edit/delete notifications and typing frames; unlike
+// Broadcast, these are never persisted, so a sibling that's down simply
+// misses them rather than replaying them later.
+func (h *Hub) BroadcastFrame(room, kind string, payload interface{}) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error encoding frame: %v", err)
+		return
+	}
+	data, err := json.Marshal(Frame{Kind: kind, Payload: raw})
+	if err != nil {
+		log.Printf("Error encoding frame: %v", err)
+		return
+	}
+	h.frames <- roomFrame{room: room, data: data}
+
+	go func() {
+		if err := h.broker.PublishFrame(context.Background(), broker.Frame{Room: room, Kind: kind, Payload: raw}); err != nil {
+			log.Printf("Error publishing frame to broker: %v", err)
+		}
+	}()
+}
+
+// BroadcastRemoteFrame delivers a frame published by another instance to
+// this instance's local subscribers, without re-publishing it: the
+// instance that originated it already did.
+func (h *Hub) BroadcastRemoteFrame(frame broker.Frame) {
+	data, err := json.Marshal(Frame{Kind: frame.Kind, Payload: frame.Payload})
+	if err != nil {
+		log.Printf("Error encoding remote frame: %v", err)
+		return
+	}
+	h.frames <- roomFrame{room: frame.Room, data: data}
+}
+
+// SetBroker replaces the hub's fan-out broker. It must be called before
+// Run starts processing broadcasts, since broker is read without a lock.
+func (h *Hub) SetBroker(b broker.Broker) {
+	h.broker = b
+}
+
+// Subscribe joins client to room, creating the room if it doesn't exist yet.
+func (h *Hub) Subscribe(client *Client, room string) {
+	h.subscribe <- subscription{client: client, room: room}
+}
+
+// Unsubscribe removes client from room.
+func (h *Hub) Unsubscribe(client *Client, room string) {
+	h.unsubscribe <- subscription{client: client, room: room}
+}
+
+// ClientCount returns the number of currently registered clients.
+func (h *Hub) ClientCount() int {
+	reply := make(chan int)
+	h.count <- reply
+	return <-reply
+}
+
+// RoomStats returns the number of subscribers for every active room.
+func (h *Hub) RoomStats() map[string]int {
+	reply := make(chan map[string]int)
+	h.roomStats <- reply
+	return <-reply
+}
@@ -1,221 +1,417 @@
-package main
-
-import (
-	"encoding/json"
-	"log"
-	"net/http"
-	"strconv"
-	"sync"
-	"time"
-
-	"golang.org/x/net/websocket"
-)
-
-// Message represents a chat message
-type Message struct {
-	ID        int       `json:"id"`
-	Username  string    `json:"username"`
-	Text      string    `json:"text"`
-	Timestamp time.Time `json:"timestamp"`
-}
-
-// Client represents a connected user
-type Client struct {
-	Username string
-	Conn     *websocket.Conn
-}
-
-// Global variables
-var (
-	// Database (in-memory)
-	messages   []Message
-	messageID  int
-	messageMux sync.RWMutex
-
-	// WebSocket
-	clients   = make(map[*Client]bool)
-	broadcast = make(chan Message)
-)
-
-// InitDB initializes the in-memory storage
-func InitDB() {
-	log.Println("Using in-memory storage for messages")
-	messages = make([]Message, 0)
-	messageID = 1
-}
-
-// SaveMessage saves a message to memory
-func SaveMessage(username, text string) error {
-	messageMux.Lock()
-	defer messageMux.Unlock()
-
-	message := Message{
-		ID:        messageID,
-		Username:  username,
-		Text:      text,
-		Timestamp: time.Now(),
-	}
-
-	messages = append(messages, message)
-	messageID++
-
-	log.Printf("Message saved: %s: %s", username, text)
-	return nil
-}
-
-// GetMessages retrieves messages from memory
-func GetMessages(limit int) ([]Message, error) {
-	messageMux.RLock()
-	defer messageMux.RUnlock()
-
-	if limit <= 0 {
-		limit = 50 // Default limit
-	}
-
-	start := 0
-	if len(messages) > limit {
-		start = len(messages) - limit
-	}
-
-	// Return a copy to avoid race conditions
-	result := make([]Message, len(messages)-start)
-	copy(result, messages[start:])
-
-	return result, nil
-}
-
-// GetMessageCount returns the total number of messages
-func GetMessageCount() int {
-	messageMux.RLock()
-	defer messageMux.RUnlock()
-	return len(messages)
-}
-
-// serveHome serves the HTML file
-func serveHome(w http.ResponseWriter, r *http.Request) {
-	http.ServeFile(w, r, "static/index.html")
-}
-
-// handleConnections handles WebSocket connections
-func handleConnections(ws *websocket.Conn) {
-	defer ws.Close()
-
-	// Read username from query parameter
-	username := ws.Request().URL.Query().Get("username")
-	if username == "" {
-		username = "Anonymous"
-	}
-
-	client := &Client{Username: username, Conn: ws}
-	clients[client] = true
-
-	log.Printf("Client connected: %s (Total clients: %d)", username, len(clients))
-
-	// Send message history to new client
-	messages, err := GetMessages(100)
-	if err == nil {
-		for _, msg := range messages {
-			websocket.JSON.Send(ws, msg)
-		}
-	}
-
-	for {
-		var msg Message
-		err := websocket.JSON.Receive(ws, &msg)
-		if err != nil {
-			log.Printf("Error reading JSON from %s: %v", username, err)
-			delete(clients, client)
-			log.Printf("Client disconnected: %s (Total clients: %d)", username, len(clients))
-			break
-		}
-
-		msg.Username = username
-		msg.Timestamp = time.Now()
-
-		// Save to memory
-		err = SaveMessage(msg.Username, msg.Text)
-		if err != nil {
-			log.Printf("Error saving message: %v", err)
-		}
-
-		// Broadcast to all clients
-		broadcast <- msg
-	}
-}
-
-// handleMessages processes incoming messages and broadcasts them
-func handleMessages() {
-	for {
-		msg := <-broadcast
-		for client := range clients {
-			err := websocket.JSON.Send(client.Conn, msg)
-			if err != nil {
-				log.Printf("WebSocket error for %s: %v", client.Username, err)
-				client.Conn.Close()
-				delete(clients, client)
-				log.Printf("Client removed due to error: %s", client.Username)
-			}
-		}
-	}
-}
-
-// getMessages returns the latest messages
-func getMessages(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	limitStr := r.URL.Query().Get("limit")
-	limit := 100 // default limit
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
-	}
-
-	messages, err := GetMessages(limit)
-	if err != nil {
-		http.Error(w, "Error retrieving messages", http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(messages)
-}
-
-// getStats returns basic statistics
-func getStats(w http.ResponseWriter, r *http.Request) {
-	stats := map[string]interface{}{
-		"total_messages": GetMessageCount(),
-		"active_clients": len(clients),
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(stats)
-}
-
-func main() {
-	// Initialize in-memory storage
-	InitDB()
-
-	// Start message handler goroutine
-	go handleMessages()
-
-	// Setup HTTP routes
-	http.HandleFunc("/", serveHome)
-	http.Handle("/ws", websocket.Handler(handleConnections))
-	http.HandleFunc("/messages", getMessages)
-	http.HandleFunc("/stats", getStats)
-	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
-
-	// Start the server
-	log.Println("Server starting on :8080")
-	log.Println("Using in-memory storage - messages will be lost on server restart")
-	log.Println("Visit http://localhost:8080 to access the chat")
-
-	err := http.ListenAndServe(":8080", nil)
-	if err != nil {
-		log.Fatal("Error starting server: ", err)
-	}
-}
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/Akshaylinson/Realtime-chatapp-go/auth"
+	"github.com/Akshaylinson/Realtime-chatapp-go/bridge"
+	"github.com/Akshaylinson/Realtime-chatapp-go/broker"
+	"github.com/Akshaylinson/Realtime-chatapp-go/store"
+)
+
+// Message is the wire/storage representation of a chat message.
+type Message = store.Message
+
+// Global variables
+var (
+	// messageStore persists messages; see InitStore for backend selection.
+	messageStore store.MessageStore
+
+	// messageBroker fans locally-accepted messages out to other server
+	// instances; see InitBroker for backend selection.
+	messageBroker broker.Broker = broker.NoopBroker{}
+
+	// tokenStore authenticates each client's auth_request frame; see
+	// InitAuth for backend selection.
+	tokenStore auth.TokenStore = auth.OpenTokenStore{}
+
+	// gateway relays messages to and from external networks (IRC, Matrix,
+	// webhooks) per InitBridges' config file. It is nil when no bridge
+	// config is configured, meaning this instance relays nowhere.
+	gateway *bridge.Gateway
+
+	// hub owns every connected client and serializes broadcasts.
+	hub = NewHub()
+
+	upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     func(r *http.Request) bool { return true },
+	}
+)
+
+// InitStore selects and opens the message store backend named by kind,
+// which is one of "memory", "sqlite" or "redis". sqlitePath and redisAddr
+// configure their respective backends and are ignored otherwise.
+func InitStore(kind, sqlitePath, redisAddr string) error {
+	switch kind {
+	case "", "memory":
+		log.Println("Using in-memory storage - messages will be lost on server restart")
+		messageStore = store.NewMemoryStore()
+
+	case "sqlite":
+		log.Printf("Using SQLite storage at %s", sqlitePath)
+		db, err := store.NewSQLiteStore(sqlitePath)
+		if err != nil {
+			return err
+		}
+		messageStore = db
+
+	case "redis":
+		log.Printf("Using Redis storage at %s", redisAddr)
+		messageStore = store.NewRedisStore(redisAddr)
+
+	default:
+		log.Fatalf("Unknown CHAT_STORE/--store backend: %q", kind)
+	}
+
+	return nil
+}
+
+// InitBroker selects the broker used to fan messages out between server
+// instances, one of "" (default, standalone, keeping the NoopBroker) or
+// "redis", in which case messages are replicated via Redis Pub/Sub at addr
+// so every instance behind a load balancer sees every message.
+func InitBroker(kind, addr string) error {
+	switch kind {
+	case "":
+		return nil
+
+	case "redis":
+		log.Printf("Using Redis broker at %s for cross-instance fan-out", addr)
+		b, err := broker.NewRedisBroker(addr)
+		if err != nil {
+			return err
+		}
+		messageBroker = b
+
+	default:
+		log.Fatalf("Unknown CHAT_BROKER/--broker backend: %q", kind)
+	}
+
+	return nil
+}
+
+// InitBridges loads a Gateway from the config file at path and starts it
+// relaying to and from its configured external networks. An empty path
+// leaves gateway nil, meaning this instance doesn't bridge anywhere.
+func InitBridges(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	cfg, err := bridge.LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	gw, err := bridge.NewGateway(cfg)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Using bridge config at %s for cross-network relay", path)
+	gateway = gw
+	go gateway.Run(context.Background(), func(msg Message) (Message, error) {
+		return SaveMessage(msg.Room, msg.Username, msg.UserID, msg.Text)
+	}, hub.Broadcast)
+	return nil
+}
+
+// RelayToBridges relays a locally-accepted message out to every external
+// network linked to its room, if this instance has a gateway configured.
+func RelayToBridges(msg Message) {
+	if gateway != nil {
+		gateway.Relay(msg)
+	}
+}
+
+// InitAuth selects the token store used to validate each client's
+// auth_request frame, which is one of "static" or "jwt". tokens configures
+// StaticTokenStore as a "nickname:token,..." list; jwtSecret configures
+// JWTTokenStore. If mode is empty and tokens is also empty, the server
+// falls back to OpenTokenStore and warns, since that accepts any client.
+func InitAuth(mode, tokens, jwtSecret string) error {
+	switch mode {
+	case "", "static":
+		if mode == "" && tokens == "" {
+			log.Println("No auth tokens configured - accepting any nickname (dev only, do not use in production)")
+			tokenStore = auth.OpenTokenStore{}
+			return nil
+		}
+
+		static, err := auth.ParseStaticTokens(tokens)
+		if err != nil {
+			return err
+		}
+		log.Printf("Using static auth token store with %d configured nicknames", len(static))
+		tokenStore = static
+
+	case "jwt":
+		if jwtSecret == "" {
+			log.Fatal("--auth-jwt-secret (or CHAT_AUTH_JWT_SECRET) is required for --auth-mode=jwt")
+		}
+		log.Println("Using HS256 JWT auth token store")
+		tokenStore = auth.JWTTokenStore{Secret: []byte(jwtSecret)}
+
+	default:
+		log.Fatalf("Unknown CHAT_AUTH_MODE/--auth-mode backend: %q", mode)
+	}
+
+	return nil
+}
+
+// SaveMessage saves a message to room's history and returns the stored copy.
+func SaveMessage(room, username, userID, text string) (Message, error) {
+	message, err := messageStore.Save(context.Background(), Message{
+		Room:     room,
+		Username: username,
+		UserID:   userID,
+		Text:     text,
+	})
+	if err != nil {
+		return Message{}, err
+	}
+
+	log.Printf("Message saved in %s: %s: %s", room, username, text)
+	return message, nil
+}
+
+// EditMessage updates the text of message id in room, provided userID
+// authored it, and returns the updated message.
+func EditMessage(room string, id int, userID, text string) (Message, error) {
+	return messageStore.Edit(context.Background(), room, id, userID, text)
+}
+
+// DeleteMessage marks message id in room as deleted, provided userID
+// authored it.
+func DeleteMessage(room string, id int, userID string) error {
+	return messageStore.Delete(context.Background(), room, id, userID)
+}
+
+// GetMessages retrieves the most recent messages for a single room, newest
+// page first via beforeID for pagination.
+func GetMessages(room string, limit int, beforeID int) ([]Message, error) {
+	return messageStore.List(context.Background(), room, limit, beforeID)
+}
+
+// GetMessageCount returns the number of messages stored for room.
+func GetMessageCount(room string) (int, error) {
+	return messageStore.Count(context.Background(), room)
+}
+
+// GetTotalMessageCount returns the number of messages stored across every
+// known room, used for the overall /stats figure. Rooms are discovered from
+// the hub's active subscriptions, since stores don't enumerate rooms.
+func GetTotalMessageCount() int {
+	total := 0
+	for room := range hub.RoomStats() {
+		if count, err := GetMessageCount(room); err == nil {
+			total += count
+		}
+	}
+	return total
+}
+
+// serveHome serves the HTML file
+func serveHome(w http.ResponseWriter, r *http.Request) {
+	http.ServeFile(w, r, "static/index.html")
+}
+
+// serveWs upgrades the HTTP connection to a WebSocket, registers a Client
+// with the hub, and starts its read/write pumps.
+func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+
+	rooms := parseRooms(r.URL.Query().Get("rooms"))
+	if len(rooms) == 0 {
+		rooms = []string{defaultRoom}
+	}
+
+	client := &Client{
+		hub:  hub,
+		conn: conn,
+		send: make(chan []byte, clientSendBuffer),
+	}
+
+	// Registration and room subscription happen once the client sends a
+	// valid auth_request frame; see Client.authenticate.
+	go client.writePump()
+	go client.readPump(rooms)
+}
+
+// parseRooms splits a comma-separated "rooms" query value, trimming
+// whitespace and dropping empty entries.
+func parseRooms(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+
+	var rooms []string
+	for _, room := range strings.Split(raw, ",") {
+		room = strings.TrimSpace(room)
+		if room != "" {
+			rooms = append(rooms, room)
+		}
+	}
+	return rooms
+}
+
+// getMessages returns the latest messages
+func getMessages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	room := r.URL.Query().Get("room")
+	if room == "" {
+		room = defaultRoom
+	}
+
+	limitStr := r.URL.Query().Get("limit")
+	limit := 100 // default limit
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	beforeID := 0
+	if beforeStr := r.URL.Query().Get("before_id"); beforeStr != "" {
+		if b, err := strconv.Atoi(beforeStr); err == nil && b > 0 {
+			beforeID = b
+		}
+	}
+
+	messages, err := GetMessages(room, limit, beforeID)
+	if err != nil {
+		http.Error(w, "Error retrieving messages", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(messages)
+}
+
+// getRooms enumerates active rooms and their subscriber counts.
+func getRooms(w http.ResponseWriter, r *http.Request) {
+	stats := hub.RoomStats()
+
+	rooms := make([]map[string]interface{}, 0, len(stats))
+	for room, subscribers := range stats {
+		rooms = append(rooms, map[string]interface{}{
+			"room":        room,
+			"subscribers": subscribers,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rooms)
+}
+
+// getStats returns basic statistics
+func getStats(w http.ResponseWriter, r *http.Request) {
+	stats := map[string]interface{}{
+		"total_messages": GetTotalMessageCount(),
+		"active_clients": hub.ClientCount(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+func main() {
+	storeKind := flag.String("store", os.Getenv("CHAT_STORE"), "message store backend: memory, sqlite, redis (env CHAT_STORE)")
+	sqlitePath := flag.String("sqlite-path", envOr("CHAT_SQLITE_PATH", "chat.db"), "SQLite database file (env CHAT_SQLITE_PATH)")
+	redisAddr := flag.String("redis-addr", envOr("CHAT_REDIS_ADDR", "localhost:6379"), "Redis address (env CHAT_REDIS_ADDR)")
+	brokerKind := flag.String("broker", os.Getenv("CHAT_BROKER"), "cross-instance fan-out broker: redis; unset runs standalone (env CHAT_BROKER)")
+	authMode := flag.String("auth-mode", os.Getenv("CHAT_AUTH_MODE"), "auth token backend: static, jwt (env CHAT_AUTH_MODE)")
+	authTokens := flag.String("auth-tokens", os.Getenv("CHAT_AUTH_TOKENS"), "static auth-mode tokens as nickname:token,... (env CHAT_AUTH_TOKENS)")
+	authJWTSecret := flag.String("auth-jwt-secret", os.Getenv("CHAT_AUTH_JWT_SECRET"), "HS256 secret for jwt auth-mode (env CHAT_AUTH_JWT_SECRET)")
+	bridgeConfig := flag.String("bridge-config", os.Getenv("CHAT_BRIDGE_CONFIG"), "YAML config mapping rooms to external network bridges; unset disables relaying (env CHAT_BRIDGE_CONFIG)")
+	flag.Parse()
+
+	if err := InitStore(*storeKind, *sqlitePath, *redisAddr); err != nil {
+		log.Fatalf("Error initializing message store: %v", err)
+	}
+
+	if err := InitBroker(*brokerKind, *redisAddr); err != nil {
+		log.Fatalf("Error initializing broker: %v", err)
+	}
+	hub.SetBroker(messageBroker)
+
+	if err := InitAuth(*authMode, *authTokens, *authJWTSecret); err != nil {
+		log.Fatalf("Error initializing auth: %v", err)
+	}
+
+	if err := InitBridges(*bridgeConfig); err != nil {
+		log.Fatalf("Error initializing bridges: %v", err)
+	}
+
+	// Start the hub's single serializing goroutine
+	go hub.Run()
+
+	// Relay messages published by other instances into this hub.
+	go func() {
+		remoteMessages, err := messageBroker.Start(context.Background())
+		if err != nil {
+			log.Fatalf("Error starting broker: %v", err)
+		}
+		for msg := range remoteMessages {
+			hub.BroadcastRemote(msg)
+		}
+	}()
+
+	// Relay edit/delete/typing frames published by other instances into
+	// this hub.
+	go func() {
+		remoteFrames, err := messageBroker.StartFrames(context.Background())
+		if err != nil {
+			log.Fatalf("Error starting broker frame relay: %v", err)
+		}
+		for frame := range remoteFrames {
+			hub.BroadcastRemoteFrame(frame)
+		}
+	}()
+
+	// Setup HTTP routes
+	http.HandleFunc("/", serveHome)
+	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		serveWs(hub, w, r)
+	})
+	http.HandleFunc("/messages", getMessages)
+	http.HandleFunc("/rooms", getRooms)
+	http.HandleFunc("/stats", getStats)
+	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
+
+	// Start the server
+	log.Println("Server starting on :8080")
+	log.Println("Visit http://localhost:8080 to access the chat")
+
+	err := http.ListenAndServe(":8080", nil)
+	if err != nil {
+		log.Fatal("Error starting server: ", err)
+	}
+}
+
+// envOr returns the value of environment variable key, or def if unset.
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
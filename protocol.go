@@ -0,0 +1,95 @@
+package main
+
+import "encoding/json"
+
+// Frame is the envelope for every message exchanged over the WebSocket, in
+// both directions. Kind discriminates how Payload should be decoded, which
+// lets the protocol grow new interactions without changing the wire shape.
+type Frame struct {
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Frame kinds.
+const (
+	frameAuthRequest   = "auth_request"
+	frameAuthResponse  = "auth_response"
+	frameSubscribe     = "subscribe"
+	frameUnsubscribe   = "unsubscribe"
+	frameChatMessage   = "chat_message"
+	frameEditMessage   = "edit_message"
+	frameDeleteMessage = "delete_message"
+	frameTyping        = "typing"
+	framePresence      = "presence"
+	frameHistory       = "history"
+	frameError         = "error"
+)
+
+// authRequestPayload is the first frame a client must send: its nickname
+// and a token to prove it, checked against the server's configured
+// auth.TokenStore.
+type authRequestPayload struct {
+	Nickname string `json:"nickname"`
+	Token    string `json:"token"`
+}
+
+// authResponsePayload answers an auth_request. UserID is set only when
+// Success is true.
+type authResponsePayload struct {
+	Success bool   `json:"success"`
+	UserID  string `json:"user_id,omitempty"`
+}
+
+// subscribePayload names the room to join or leave.
+type subscribePayload struct {
+	Room string `json:"room"`
+}
+
+// chatMessagePayload is a new message a client wants to send.
+type chatMessagePayload struct {
+	Room string `json:"room"`
+	Text string `json:"text"`
+}
+
+// editMessagePayload requests a text change to a message the client
+// authored.
+type editMessagePayload struct {
+	Room string `json:"room"`
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+}
+
+// deleteMessagePayload requests removal of a message the client authored.
+type deleteMessagePayload struct {
+	Room string `json:"room"`
+	ID   int    `json:"id"`
+}
+
+// typingPayload announces that a user is composing a message in room.
+type typingPayload struct {
+	Room     string `json:"room"`
+	Username string `json:"username"`
+}
+
+// presencePayload announces a user joining or leaving room.
+type presencePayload struct {
+	Room     string `json:"room"`
+	Username string `json:"username"`
+	Online   bool   `json:"online"`
+}
+
+// errorPayload carries a human-readable problem description back to the
+// client that caused it.
+type errorPayload struct {
+	Message string `json:"message"`
+}
+
+// encodeFrame marshals payload and wraps it in a Frame of the given kind,
+// ready to send on a Client's send channel.
+func encodeFrame(kind string, payload interface{}) ([]byte, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Frame{Kind: kind, Payload: raw})
+}
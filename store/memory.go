@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is the original in-memory backend: a per-room slice guarded
+// by a single mutex. Nothing is persisted across restarts.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	byRoom map[string][]Message
+	nextID int
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		byRoom: make(map[string][]Message),
+		nextID: 1,
+	}
+}
+
+// Save appends msg to room's history and assigns it the next ID.
+func (s *MemoryStore) Save(ctx context.Context, msg Message) (Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg.ID = s.nextID
+	msg.Timestamp = time.Now()
+	s.nextID++
+
+	s.byRoom[msg.Room] = append(s.byRoom[msg.Room], msg)
+	return msg, nil
+}
+
+// List returns up to limit messages from room, oldest-first, optionally
+// paging backwards from beforeID.
+func (s *MemoryStore) List(ctx context.Context, room string, limit, beforeID int) ([]Message, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if limit <= 0 {
+		limit = 50
+	}
+
+	history := s.byRoom[room]
+
+	end := len(history)
+	if beforeID > 0 {
+		end = 0
+		for i, msg := range history {
+			if msg.ID >= beforeID {
+				break
+			}
+			end = i + 1
+		}
+	}
+
+	start := 0
+	if end > limit {
+		start = end - limit
+	}
+
+	result := make([]Message, end-start)
+	copy(result, history[start:end])
+	return result, nil
+}
+
+// Count returns the number of messages stored for room.
+func (s *MemoryStore) Count(ctx context.Context, room string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.byRoom[room]), nil
+}
+
+// Edit updates the text of message id in room, provided userID authored it.
+func (s *MemoryStore) Edit(ctx context.Context, room string, id int, userID, text string) (Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := s.byRoom[room]
+	for i, msg := range history {
+		if msg.ID != id {
+			continue
+		}
+		if msg.Deleted {
+			return Message{}, ErrNotFound
+		}
+		if msg.UserID != userID {
+			return Message{}, ErrForbidden
+		}
+
+		now := time.Now()
+		history[i].Text = text
+		history[i].EditedAt = &now
+		return history[i], nil
+	}
+	return Message{}, ErrNotFound
+}
+
+// Delete marks message id in room as deleted, provided userID authored it.
+func (s *MemoryStore) Delete(ctx context.Context, room string, id int, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := s.byRoom[room]
+	for i, msg := range history {
+		if msg.ID != id {
+			continue
+		}
+		if msg.Deleted {
+			return nil
+		}
+		if msg.UserID != userID {
+			return ErrForbidden
+		}
+
+		history[i].Deleted = true
+		history[i].Text = ""
+		return nil
+	}
+	return ErrNotFound
+}
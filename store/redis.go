@@ -0,0 +1,218 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore persists messages on a per-room Redis stream
+// (chat:room:<room>), giving durability plus cheap tailing for subscribers.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a RedisStore backed by the server at addr.
+func NewRedisStore(addr string) *RedisStore {
+	return &RedisStore{
+		client: redis.NewClient(&redis.Options{Addr: addr}),
+	}
+}
+
+// Close releases the underlying Redis connection pool.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+func streamKey(room string) string {
+	return "chat:room:" + room
+}
+
+// messageKey is the hash holding the current, possibly-edited-or-deleted
+// state of a single message. The stream only records its ID and ordering;
+// this hash is the source of truth for Edit and Delete, since stream
+// entries themselves are append-only and can't be mutated in place.
+func messageKey(room string, id int) string {
+	return fmt.Sprintf("chat:msg:%s:%d", room, id)
+}
+
+// streamEntryID renders id as a Redis stream entry ID (ms-seq form, with
+// seq pinned to 0), so the stream's own ordering and range queries can be
+// driven directly by our app-level ids instead of maintaining a separate
+// index from id to the stream's auto-assigned entry ID.
+func streamEntryID(id int) string {
+	return strconv.Itoa(id) + "-0"
+}
+
+// parseStreamEntryID recovers the app-level id streamEntryID encoded.
+func parseStreamEntryID(entryID string) (int, error) {
+	ms, _, ok := strings.Cut(entryID, "-")
+	if !ok {
+		return 0, fmt.Errorf("malformed entry id %q", entryID)
+	}
+	return strconv.Atoi(ms)
+}
+
+// Save appends msg to room's stream via XADD, using msg.ID as the entry's
+// own ID so List can range the stream directly by id.
+func (s *RedisStore) Save(ctx context.Context, msg Message) (Message, error) {
+	id, err := s.client.Incr(ctx, "chat:msgid:"+msg.Room).Result()
+	if err != nil {
+		return Message{}, fmt.Errorf("incrementing message id: %w", err)
+	}
+	msg.ID = int(id)
+	msg.Timestamp = time.Now()
+
+	if _, err := s.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamKey(msg.Room),
+		ID:     streamEntryID(msg.ID),
+	}).Result(); err != nil {
+		return Message{}, fmt.Errorf("publishing to stream: %w", err)
+	}
+
+	if err := s.saveState(ctx, msg); err != nil {
+		return Message{}, err
+	}
+	return msg, nil
+}
+
+// List returns up to limit messages from room, oldest-first, optionally
+// paging backwards from beforeID. It ranges the stream backwards bounded
+// by limit rather than reading the whole room history and truncating in
+// Go, so a history load costs O(limit) instead of O(room size).
+func (s *RedisStore) List(ctx context.Context, room string, limit, beforeID int) ([]Message, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	start := "+"
+	if beforeID > 0 {
+		start = "(" + streamEntryID(beforeID)
+	}
+
+	entries, err := s.client.XRevRangeN(ctx, streamKey(room), start, "-", int64(limit)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("reading stream: %w", err)
+	}
+
+	// XRevRangeN comes back newest-first; callers expect oldest-first like
+	// the other backends.
+	messages := make([]Message, len(entries))
+	for i, entry := range entries {
+		id, err := parseStreamEntryID(entry.ID)
+		if err != nil {
+			return nil, fmt.Errorf("parsing stream entry id: %w", err)
+		}
+		msg, err := s.loadState(ctx, room, id)
+		if err != nil {
+			return nil, err
+		}
+		messages[len(entries)-1-i] = msg
+	}
+	return messages, nil
+}
+
+// Count returns the number of messages stored for room.
+func (s *RedisStore) Count(ctx context.Context, room string) (int, error) {
+	n, err := s.client.XLen(ctx, streamKey(room)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("counting stream entries: %w", err)
+	}
+	return int(n), nil
+}
+
+// Edit updates the text of message id in room, provided userID authored it.
+func (s *RedisStore) Edit(ctx context.Context, room string, id int, userID, text string) (Message, error) {
+	msg, err := s.loadState(ctx, room, id)
+	if err != nil {
+		return Message{}, err
+	}
+	if msg.Deleted {
+		return Message{}, ErrNotFound
+	}
+	if msg.UserID != userID {
+		return Message{}, ErrForbidden
+	}
+
+	now := time.Now()
+	msg.Text = text
+	msg.EditedAt = &now
+	if err := s.saveState(ctx, msg); err != nil {
+		return Message{}, err
+	}
+	return msg, nil
+}
+
+// Delete marks message id in room as deleted, provided userID authored it.
+func (s *RedisStore) Delete(ctx context.Context, room string, id int, userID string) error {
+	msg, err := s.loadState(ctx, room, id)
+	if err != nil {
+		return err
+	}
+	if msg.UserID != userID {
+		return ErrForbidden
+	}
+
+	msg.Deleted = true
+	msg.Text = ""
+	return s.saveState(ctx, msg)
+}
+
+// saveState writes msg's current fields to its message hash.
+func (s *RedisStore) saveState(ctx context.Context, msg Message) error {
+	fields := map[string]interface{}{
+		"room":      msg.Room,
+		"username":  msg.Username,
+		"user_id":   msg.UserID,
+		"text":      msg.Text,
+		"timestamp": msg.Timestamp.Format(time.RFC3339Nano),
+		"deleted":   strconv.FormatBool(msg.Deleted),
+	}
+	if msg.EditedAt != nil {
+		fields["edited_at"] = msg.EditedAt.Format(time.RFC3339Nano)
+	}
+
+	if err := s.client.HSet(ctx, messageKey(msg.Room, msg.ID), fields).Err(); err != nil {
+		return fmt.Errorf("saving message %d: %w", msg.ID, err)
+	}
+	return nil
+}
+
+// loadState reads message id's current fields back from its hash, or
+// ErrNotFound if it (or the hash) doesn't exist.
+func (s *RedisStore) loadState(ctx context.Context, room string, id int) (Message, error) {
+	data, err := s.client.HGetAll(ctx, messageKey(room, id)).Result()
+	if err != nil {
+		return Message{}, fmt.Errorf("loading message %d: %w", id, err)
+	}
+	if len(data) == 0 {
+		return Message{}, ErrNotFound
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, data["timestamp"])
+	if err != nil {
+		return Message{}, fmt.Errorf("parsing message timestamp: %w", err)
+	}
+
+	msg := Message{
+		ID:        id,
+		Room:      room,
+		Username:  data["username"],
+		UserID:    data["user_id"],
+		Text:      data["text"],
+		Timestamp: ts,
+		Deleted:   data["deleted"] == "true",
+	}
+	if raw := data["edited_at"]; raw != "" {
+		editedAt, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return Message{}, fmt.Errorf("parsing edited_at: %w", err)
+		}
+		msg.EditedAt = &editedAt
+	}
+	return msg, nil
+}
@@ -0,0 +1,233 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStore persists messages to a SQLite database file, so history
+// survives a server restart.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// sqliteMigrations are applied in order, each exactly once, to bring a
+// database up to the current schema. Step 0 is the original chunk0-3
+// schema (bare messages table); later steps ALTER it rather than
+// redeclaring it, so a chat.db created by an older server still gets the
+// columns it's missing instead of silently keeping its old schema.
+var sqliteMigrations = []string{
+	`CREATE TABLE IF NOT EXISTS messages (
+		id        INTEGER PRIMARY KEY AUTOINCREMENT,
+		room      TEXT NOT NULL,
+		username  TEXT NOT NULL,
+		text      TEXT NOT NULL,
+		timestamp DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_messages_room_id ON messages (room, id);`,
+
+	`ALTER TABLE messages ADD COLUMN user_id TEXT NOT NULL DEFAULT '';`,
+
+	`ALTER TABLE messages ADD COLUMN edited_at DATETIME;`,
+
+	`ALTER TABLE messages ADD COLUMN deleted INTEGER NOT NULL DEFAULT 0;`,
+}
+
+// migrate applies every sqliteMigrations step not yet applied to db,
+// tracking progress in SQLite's built-in user_version pragma so each step
+// runs exactly once even when upgrading straight from an older schema.
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+
+	for ; version < len(sqliteMigrations); version++ {
+		if _, err := db.Exec(sqliteMigrations[version]); err != nil {
+			return fmt.Errorf("applying migration %d: %w", version+1, err)
+		}
+		if _, err := db.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version+1)); err != nil {
+			return fmt.Errorf("recording schema version %d: %w", version+1, err)
+		}
+	}
+	return nil
+}
+
+// NewSQLiteStore opens (creating if necessary) the SQLite database at path
+// and migrates its schema to the current version.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("opening sqlite database: %w", err)
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating schema: %w", err)
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// Close releases the underlying database handle.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// Save inserts msg and returns it with its assigned ID and timestamp.
+func (s *SQLiteStore) Save(ctx context.Context, msg Message) (Message, error) {
+	msg.Timestamp = time.Now()
+
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO messages (room, username, user_id, text, timestamp) VALUES (?, ?, ?, ?, ?)`,
+		msg.Room, msg.Username, msg.UserID, msg.Text, msg.Timestamp,
+	)
+	if err != nil {
+		return Message{}, fmt.Errorf("inserting message: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Message{}, fmt.Errorf("reading inserted id: %w", err)
+	}
+	msg.ID = int(id)
+
+	return msg, nil
+}
+
+// List returns up to limit messages from room, oldest-first, optionally
+// paging backwards from beforeID.
+func (s *SQLiteStore) List(ctx context.Context, room string, limit, beforeID int) ([]Message, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `
+		SELECT id, room, username, user_id, text, timestamp, edited_at, deleted FROM messages
+		WHERE room = ? AND (? = 0 OR id < ?)
+		ORDER BY id DESC
+		LIMIT ?
+	`
+	rows, err := s.db.QueryContext(ctx, query, room, beforeID, beforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing messages: %w", err)
+	}
+	defer rows.Close()
+
+	var result []Message
+	for rows.Next() {
+		msg, err := scanMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning message: %w", err)
+		}
+		result = append(result, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Rows come back newest-first from the query; callers expect
+	// oldest-first like the other backends.
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+
+	return result, nil
+}
+
+// Count returns the number of messages stored for room.
+func (s *SQLiteStore) Count(ctx context.Context, room string) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM messages WHERE room = ?`, room).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("counting messages: %w", err)
+	}
+	return count, nil
+}
+
+// Edit updates the text of message id in room, provided userID authored it.
+func (s *SQLiteStore) Edit(ctx context.Context, room string, id int, userID, text string) (Message, error) {
+	msg, err := s.lookup(ctx, room, id)
+	if err != nil {
+		return Message{}, err
+	}
+	if msg.UserID != userID {
+		return Message{}, ErrForbidden
+	}
+
+	now := time.Now()
+	if _, err := s.db.ExecContext(ctx,
+		`UPDATE messages SET text = ?, edited_at = ? WHERE id = ? AND room = ?`,
+		text, now, id, room,
+	); err != nil {
+		return Message{}, fmt.Errorf("updating message: %w", err)
+	}
+
+	msg.Text = text
+	msg.EditedAt = &now
+	return msg, nil
+}
+
+// Delete marks message id in room as deleted, provided userID authored it.
+func (s *SQLiteStore) Delete(ctx context.Context, room string, id int, userID string) error {
+	msg, err := s.lookup(ctx, room, id)
+	if err != nil {
+		return err
+	}
+	if msg.UserID != userID {
+		return ErrForbidden
+	}
+
+	if _, err := s.db.ExecContext(ctx,
+		`UPDATE messages SET text = '', deleted = 1 WHERE id = ? AND room = ?`,
+		id, room,
+	); err != nil {
+		return fmt.Errorf("deleting message: %w", err)
+	}
+	return nil
+}
+
+// lookup fetches the current (non-deleted) state of message id in room, or
+// ErrNotFound if it doesn't exist or has already been deleted.
+func (s *SQLiteStore) lookup(ctx context.Context, room string, id int) (Message, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT id, room, username, user_id, text, timestamp, edited_at, deleted FROM messages WHERE id = ? AND room = ?`,
+		id, room,
+	)
+	msg, err := scanMessage(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Message{}, ErrNotFound
+	}
+	if err != nil {
+		return Message{}, fmt.Errorf("looking up message: %w", err)
+	}
+	if msg.Deleted {
+		return Message{}, ErrNotFound
+	}
+	return msg, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows, letting
+// scanMessage serve single-row lookups and multi-row listings alike.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMessage(row rowScanner) (Message, error) {
+	var msg Message
+	var editedAt sql.NullTime
+	var deleted int
+	if err := row.Scan(&msg.ID, &msg.Room, &msg.Username, &msg.UserID, &msg.Text, &msg.Timestamp, &editedAt, &deleted); err != nil {
+		return Message{}, err
+	}
+	if editedAt.Valid {
+		msg.EditedAt = &editedAt.Time
+	}
+	msg.Deleted = deleted != 0
+	return msg, nil
+}
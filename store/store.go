@@ -0,0 +1,49 @@
+// Package store defines the persistence interface used to save and replay
+// chat messages, plus the backends that implement it.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by Edit and Delete when no message with the given
+// room and ID exists, or it has already been deleted.
+var ErrNotFound = errors.New("message not found")
+
+// ErrForbidden is returned by Edit and Delete when userID does not match
+// the message's original author.
+var ErrForbidden = errors.New("not the message owner")
+
+// Message is a single chat message, scoped to a room.
+type Message struct {
+	ID        int        `json:"id"`
+	Room      string     `json:"room"`
+	Username  string     `json:"username"`
+	UserID    string     `json:"user_id"`
+	Text      string     `json:"text"`
+	Timestamp time.Time  `json:"timestamp"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	Deleted   bool       `json:"deleted,omitempty"`
+}
+
+// MessageStore persists messages and serves them back per room. beforeID in
+// List enables cursor-based pagination: pass the ID of the oldest message
+// already seen to fetch the page before it, or 0 to start from the newest.
+type MessageStore interface {
+	Save(ctx context.Context, msg Message) (Message, error)
+	List(ctx context.Context, room string, limit, beforeID int) ([]Message, error)
+	Count(ctx context.Context, room string) (int, error)
+
+	// Edit updates the text of the message identified by room and id,
+	// stamping EditedAt, and returns the updated message. It returns
+	// ErrForbidden if userID did not author the message, or ErrNotFound if
+	// no such (non-deleted) message exists.
+	Edit(ctx context.Context, room string, id int, userID, text string) (Message, error)
+
+	// Delete marks the message identified by room and id as deleted. It
+	// returns ErrForbidden if userID did not author the message, or
+	// ErrNotFound if no such message exists.
+	Delete(ctx context.Context, room string, id int, userID string) error
+}